@@ -5,6 +5,7 @@ import (
 
 	"github.com/ktnuity/wet/internal/app"
 	"github.com/ktnuity/wet/internal/source"
+	"github.com/ktnuity/wet/internal/types"
 	"github.com/ktnuity/wet/internal/util"
 )
 
@@ -17,6 +18,21 @@ func main() {
 			fmt.Printf("Error: %v\n", err)
 		}
 
+		util.ExitWithStatus(err)
+		return
+	}
+
+	switch args.Command {
+	case types.WetCommandHelp:
+		if args.Topic != "" {
+			fmt.Print(util.CommandHelp(args.Bin.Name, args.Topic))
+			return
+		}
+	case types.WetCommandFmt:
+		util.ExitWithStatus(app.Format(args))
+		return
+	case types.WetCommandCache:
+		util.ExitWithStatus(app.Cache(args))
 		return
 	}
 
@@ -24,6 +40,5 @@ func main() {
 	defer exit()
 
 	err = app.EntryPoint(src, args)
-	util.ExitWithError(err, util.AsRef("Runtime failure"))
+	util.ExitWithStatus(err)
 }
-
@@ -1,40 +1,131 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/ktnuity/wet/internal/interpreter"
 	"github.com/ktnuity/wet/internal/tokenizer"
+	"github.com/ktnuity/wet/internal/tools"
 	"github.com/ktnuity/wet/internal/types"
 	"github.com/ktnuity/wet/internal/util"
 )
 
-func EntryPoint(src string, args *types.WetArgs) error {
+// EntryPoint runs the program and reports any failure before returning it.
+func EntryPoint(src []types.SourceLine, args *types.WetArgs) error {
+	err := run(src, args)
+	if err != nil {
+		report(err)
+	}
+
+	return err
+}
+
+func run(src []types.SourceLine, args *types.WetArgs) error {
 	if util.HasFlag(args.Flags, types.WetFlagVerboseRuntime) {
-		fmt.Printf("Code:\n%s\n", src)
+		fmt.Printf("Code:\n%s\n", types.JoinSource(src))
 		fmt.Printf("Tokenizing code...\n")
 	}
-	tokens := tokenizer.TokenizeCode(src)
+	tokens, err := tokenizer.TokenizeCode(src)
+	if err != nil {
+		return fmt.Errorf("failed to tokenize code: %w", err)
+	}
 	if util.HasFlag(args.Flags, types.WetFlagVerboseTokenize) {
 		fmt.Printf("Tokens:\n")
 		tokenizer.LogTokens(tokens)
 	}
 
 	interpreter.SubmitFlags(args.Flags)
+	interpreter.SubmitArgs(args.ScriptArgs)
+	tools.SubmitDownloadConfig(downloadConfig(args.Download))
+
+	switch args.Command {
+	case types.WetCommandCheck:
+		return check(tokens)
+	case types.WetCommandTokens:
+		return logTokens(tokens)
+	}
 
 	intr, err := interpreter.CreateNew(tokens)
 	if err != nil {
-		fmt.Printf("Failed to init interpreter: %v\n", err)
+		return fmt.Errorf("failed to init interpreter: %w", err)
 	}
 
 	status, err := intr.Run()
 	if err != nil {
-		return fmt.Errorf("error running wet: %v", err)
+		return fmt.Errorf("error running wet: %w", err)
 	}
 
 	if !status {
-		return fmt.Errorf("error running wet.")
+		return fmt.Errorf("error running wet. interpreter stopped without a reason.")
 	}
 
 	return nil
 }
+
+// Type-checks the program without running it. Every problem is printed before the summary error is returned.
+func check(tokens []types.Token) error {
+	errs := interpreter.Check(tokens)
+	for _, err := range errs {
+		fmt.Printf("%v\n", err)
+	}
+
+	if len(errs) > 0 {
+		return &interpreter.CheckError{Count: len(errs)}
+	}
+
+	fmt.Printf("check passed.\n")
+	return nil
+}
+
+// Applies the download options over the default download settings.
+func downloadConfig(opts types.WetDownloadOptions) tools.DownloadConfig {
+	cfg := tools.DefaultDownloadConfig()
+	cfg.Timeout = time.Duration(opts.Timeout) * time.Second
+	cfg.Retries = opts.Retries
+	cfg.Refresh = opts.Refresh
+	if opts.NoProgress {
+		cfg.Progress = nil
+	}
+
+	return cfg
+}
+
+// Logs the tokens of the script and its includes. Tokens from the std are left out.
+func logTokens(tokens []types.Token) error {
+	tokens = slices.DeleteFunc(tokens, func(token types.Token) bool {
+		return token.Pos.Std
+	})
+
+	if len(tokens) == 0 {
+		fmt.Printf("Token Count: 0\n")
+		return nil
+	}
+
+	return tokenizer.LogTokens(tokens)
+}
+
+func report(err error) {
+	var re *interpreter.RuntimeError
+	var er *interpreter.ExitRequest
+	var se *types.SourceError
+	var ce *interpreter.CheckError
+	var fe *FormatError
+
+	if errors.As(err, &re) {
+		fmt.Printf("%s\n", re.Report())
+	} else if errors.As(err, &ce) {
+		fmt.Printf("%v\n", ce)
+	} else if errors.As(err, &fe) {
+		fmt.Printf("%v\n", fe)
+	} else if errors.As(err, &er) {
+		fmt.Printf("%v\n", er)
+	} else if errors.As(err, &se) {
+		// Positioned errors are reported as-is, so the file:line:col prefix leads the message.
+		fmt.Printf("%v\n", se)
+	} else {
+		fmt.Printf("Failure: %v\n", err)
+	}
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/ktnuity/wet/internal/tools"
+	"github.com/ktnuity/wet/internal/types"
+)
+
+// Cache lists the token cache or clears its downloads for 'wet cache', and reports any failure before returning it.
+func Cache(args *types.WetArgs) error {
+	err := cache(args)
+	if err != nil {
+		report(err)
+	}
+
+	return err
+}
+
+func cache(args *types.WetArgs) error {
+	dir, err := tools.TokenDir()
+	if err != nil {
+		return fmt.Errorf("failed to locate cache: %w", err)
+	}
+
+	if args.Cache.Path {
+		fmt.Printf("%s\n", dir)
+		return nil
+	}
+
+	// Token files are written by scripts, so only the downloads are cleared.
+	if args.Cache.Clear {
+		count, err := tools.ClearDownloads()
+		if err != nil {
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+
+		fmt.Printf("cleared %d downloads from %s.\n", count, dir)
+		return nil
+	}
+
+	fmt.Printf("cache: %s\n", dir)
+
+	count := 0
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Downloads are listed on their own below.
+		if path == filepath.Join(dir, tools.DownloadCacheName) {
+			return filepath.SkipDir
+		} else if entry.IsDir() || path == filepath.Join(dir, tools.DownloadIndexName) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("  %s (%d bytes)\n", filepath.ToSlash(rel), info.Size())
+		count++
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list cache: %w", err)
+	}
+
+	if count == 0 {
+		fmt.Printf("  no tokens cached.\n")
+	}
+
+	downloads, err := tools.CachedDownloads()
+	if err != nil {
+		return fmt.Errorf("failed to list cache: %w", err)
+	}
+
+	fmt.Printf("downloads:\n")
+	for _, download := range downloads {
+		fmt.Printf("  %s (%d bytes, %s)\n", download.Url, download.Size, download.Fetched.Local().Format(time.DateTime))
+	}
+
+	if len(downloads) == 0 {
+		fmt.Printf("  no downloads cached.\n")
+	}
+
+	return nil
+}
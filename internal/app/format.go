@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ktnuity/wet/internal/tokenizer"
+	"github.com/ktnuity/wet/internal/types"
+)
+
+// Format formats every file given to 'wet fmt' and reports any failure before returning it.
+func Format(args *types.WetArgs) error {
+	err := format(args)
+	if err != nil {
+		report(err)
+	}
+
+	return err
+}
+
+func format(args *types.WetArgs) error {
+	unformatted := 0
+
+	for _, file := range args.Files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("failed to format '%s': %w", file, err)
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to format '%s': %w", file, err)
+		}
+
+		formatted, err := tokenizer.Format(file, string(data))
+		if err != nil {
+			return err
+		}
+
+		changed := formatted != string(data)
+
+		if args.Fmt.Check {
+			if changed {
+				fmt.Printf("%s\n", file)
+				unformatted++
+			}
+		} else if args.Fmt.Write {
+			if changed {
+				err = os.WriteFile(file, []byte(formatted), info.Mode().Perm())
+				if err != nil {
+					return fmt.Errorf("failed to format '%s': %w", file, err)
+				}
+			}
+		} else {
+			fmt.Print(formatted)
+		}
+	}
+
+	if unformatted > 0 {
+		return &FormatError{Count: unformatted}
+	}
+
+	return nil
+}
+
+// FormatError is returned by 'wet fmt --check' when files are not formatted.
+type FormatError struct {
+	Count		int
+}
+
+func (fe *FormatError) Error() string {
+	return fmt.Sprintf("%d file(s) are not formatted.", fe.Count)
+}
+
+func (fe *FormatError) ExitCode() types.ExitCode {
+	return types.ExitCodeScript
+}
@@ -0,0 +1,814 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ktnuity/wet/internal/types"
+)
+
+// Kinds tracked by the checker. kindAny stands for a value only known at runtime.
+type kind uint8
+const (
+	kindAny kind = iota
+	kindInt
+	kindFloat
+	kindBool
+	kindString
+	kindPath
+	kindList
+	kindMap
+)
+
+var kindNames = [...]string{"any", "int", "float", "bool", "string", "path", "list", "map"}
+
+func (k kind) String() string {
+	return kindNames[k]
+}
+
+// A set of kinds accepted by an operator input.
+type kinds uint16
+
+func kindsOf(list...kind) kinds {
+	var result kinds
+	for _, k := range list {
+		result |= 1 << k
+	}
+
+	return result
+}
+
+var (
+	kindsAny = kindsOf(kindInt, kindFloat, kindBool, kindString, kindPath, kindList, kindMap)
+	kindsNumber = kindsOf(kindInt, kindFloat)
+	kindsScalar = kindsOf(kindInt, kindFloat, kindBool, kindString)
+)
+
+func (ks kinds) accepts(k kind) bool {
+	return k == kindAny || ks & (1 << k) != 0
+}
+
+func (ks kinds) String() string {
+	names := make([]string, 0, len(kindNames))
+	for k := kindInt; k <= kindMap; k++ {
+		if ks & (1 << k) != 0 {
+			names = append(names, k.String())
+		}
+	}
+
+	if len(names) == len(kindNames) - 1 {
+		return "any"
+	}
+
+	return strings.Join(names, " or ")
+}
+
+// A stack slot as seen by the checker. Tools like load push a flag that guards the
+// values directly below it, which only exist when the flag is true.
+type entry struct {
+	kind		kind
+	guard		int
+	negated		bool
+}
+
+type checkState struct {
+	stack		[]entry
+	marks		[]int
+	inputs		int
+	open		bool
+	dead		bool
+	lost		bool
+}
+
+func (st *checkState) clone() *checkState {
+	return &checkState{
+		stack: append([]entry{}, st.stack...),
+		marks: append([]int{}, st.marks...),
+		inputs: st.inputs,
+		open: st.open,
+		dead: st.dead,
+		lost: st.lost,
+	}
+}
+
+func (st *checkState) push(kinds...kind) {
+	for _, k := range kinds {
+		st.stack = append(st.stack, entry{kind: k})
+	}
+}
+
+func (st *checkState) pop() entry {
+	top := st.stack[len(st.stack) - 1]
+	st.stack = st.stack[:len(st.stack) - 1]
+	return top
+}
+
+// Proc bodies take missing values from their caller. These are added below the current stack.
+func (st *checkState) borrow(count int) {
+	fill := make([]entry, count, count + len(st.stack))
+	st.stack = append(fill, st.stack...)
+	st.inputs += count
+
+	for idx := range st.marks {
+		st.marks[idx] += count
+	}
+}
+
+type procEffect struct {
+	name		string
+	inputs		int
+	outputs		[]entry
+	known		bool
+	checking	bool
+	checked		bool
+}
+
+type checker struct {
+	program		[]Instruction
+	procs		map[int]*procEffect
+	loops		map[int]int
+	current		*procEffect
+	errors		[]error
+	// Uses of macros with fewer values on the stack than they have parameters. Also listed in errors.
+	arity		[]error
+}
+
+// Check infers the stack effect of every instruction without running anything.
+// It reports type and arity errors, and branches that leave different stack shapes.
+func Check(tokens []types.Token) []error {
+	program, err := ProcessTokens(tokens)
+	if err != nil {
+		return []error{err}
+	}
+
+	return run(program).errors
+}
+
+// CheckArity reports macros used with too few values on the stack, at the position of the use.
+// Paths where the stack depth isn't known are left to the runtime.
+func CheckArity(program []Instruction) []error {
+	return run(program).arity
+}
+
+func run(program []Instruction) *checker {
+	c := &checker{
+		program: program,
+		procs: make(map[int]*procEffect),
+		loops: make(map[int]int),
+	}
+
+	for idx := range program {
+		inst := &program[idx]
+		if inst.Token.Equals("end", types.TokenTypeKeyword) && inst.Mode == EndModeBlock && inst.Next != -1 {
+			c.loops[int(inst.Next)] = idx
+		} else if inst.Token.Equals("proc", types.TokenTypeKeyword) {
+			c.procs[idx + 2] = &procEffect{name: program[idx + 1].Token.Value}
+		}
+	}
+
+	c.block(0, len(program), &checkState{})
+
+	for idx := range program {
+		if effect, ok := c.procs[idx]; ok && !effect.checked {
+			c.checkProc(idx)
+		}
+	}
+
+	return c
+}
+
+func (c *checker) errorf(token *types.Token, format string, args...any) {
+	c.errors = append(c.errors, token.Errorf(format, args...))
+}
+
+// Makes sure count values are available. Outside of procs a missing value is an error,
+// after which the rest of the path is no longer checked.
+func (c *checker) need(st *checkState, token *types.Token, count int) bool {
+	if len(st.stack) >= count {
+		return true
+	}
+
+	if st.open {
+		st.borrow(count - len(st.stack))
+		return true
+	}
+
+	c.errorf(token, "'%s' needs %d value(s), but the stack has %d.", token.Value, count, len(st.stack))
+	st.lost = true
+	return false
+}
+
+// Pops a value and verifies its kind. A path where a string is expected gets a dedicated hint.
+func (c *checker) expect(st *checkState, token *types.Token, accepted kinds) entry {
+	top := st.pop()
+	if accepted.accepts(top.kind) {
+		return top
+	}
+
+	if top.kind == kindPath && accepted.accepts(kindString) {
+		c.errorf(token, "'%s' expects a string, but got a path. convert it with tostring first.", token.Value)
+	} else {
+		c.errorf(token, "'%s' expects %s, but got %s.", token.Value, accepted, top.kind)
+	}
+
+	return top
+}
+
+// Applies a fixed stack effect. Inputs are listed bottom to top.
+func (c *checker) apply(st *checkState, token *types.Token, inputs []kinds, outputs...kind) {
+	if !c.need(st, token, len(inputs)) {
+		return
+	}
+
+	for idx := len(inputs) - 1; idx >= 0; idx-- {
+		c.expect(st, token, inputs[idx])
+	}
+
+	st.push(outputs...)
+}
+
+// Pushes values guarded by a trailing flag, as done by load, readfile, nth and get.
+func (st *checkState) pushGuarded(k kind) {
+	st.push(k)
+	st.stack = append(st.stack, entry{kind: kindBool, guard: 1})
+}
+
+// Splits the state at a condition into the state where it holds and where it doesn't.
+func (c *checker) branch(st *checkState, cond entry) (*checkState, *checkState) {
+	truthy := st.clone()
+	falsy := st.clone()
+
+	if cond.guard > 0 {
+		missing := falsy
+		if cond.negated {
+			missing = truthy
+		}
+
+		missing.stack = missing.stack[:len(missing.stack) - cond.guard]
+	}
+
+	return truthy, falsy
+}
+
+// Pads the state that borrowed fewer values, so both describe the same caller stack.
+func align(a, b *checkState) {
+	if a.inputs < b.inputs {
+		a.borrow(b.inputs - a.inputs)
+	} else if b.inputs < a.inputs {
+		b.borrow(a.inputs - b.inputs)
+	}
+}
+
+func sameShape(a, b *checkState) bool {
+	if len(a.stack) != len(b.stack) {
+		return false
+	}
+
+	for idx := range a.stack {
+		ka := a.stack[idx].kind
+		kb := b.stack[idx].kind
+		if ka != kindAny && kb != kindAny && ka != kb {
+			return false
+		}
+	}
+
+	return true
+}
+
+func formatShape(st *checkState) string {
+	names := make([]string, len(st.stack))
+	for idx, e := range st.stack {
+		names[idx] = e.kind.String()
+	}
+
+	return "[" + strings.Join(names, " ") + "]"
+}
+
+// Joins the states of two branches. Paths that ended in exit or return don't take part.
+func (c *checker) merge(token *types.Token, a, b *checkState) *checkState {
+	aDone := a.dead || a.lost
+	bDone := b.dead || b.lost
+
+	if aDone && bDone {
+		if a.lost {
+			return a
+		}
+		return b
+	} else if aDone {
+		return b
+	} else if bDone {
+		return a
+	}
+
+	align(a, b)
+	if !sameShape(a, b) {
+		c.errorf(token, "branches of '%s' leave different stack shapes: %s and %s.", token.Value, formatShape(a), formatShape(b))
+		// Goes on with the longer stack, so the rest of the path is still checked.
+		if len(b.stack) > len(a.stack) {
+			return b
+		}
+		return a
+	}
+
+	for idx := range a.stack {
+		if a.stack[idx] != b.stack[idx] {
+			k := a.stack[idx].kind
+			if k != b.stack[idx].kind {
+				k = kindAny
+			}
+
+			a.stack[idx] = entry{kind: k}
+		}
+	}
+
+	return a
+}
+
+// Checks a loop body against the stack it started from.
+func (c *checker) loop(token *types.Token, before, after *checkState) {
+	if after.dead || after.lost {
+		return
+	}
+
+	align(before, after)
+	if !sameShape(before, after) {
+		c.errorf(token, "'%s' loop changes the stack shape from %s to %s.", token.Value, formatShape(before), formatShape(after))
+	}
+}
+
+func (c *checker) checkProc(start int) *procEffect {
+	effect := c.procs[start]
+	effect.checking = true
+
+	outer := c.current
+	c.current = effect
+
+	end := int(c.program[start - 2].Next) - 1
+	st := c.block(start, end, &checkState{open: true})
+	if !st.dead && !st.lost {
+		c.record(&c.program[end], st)
+	}
+
+	c.current = outer
+	effect.checking = false
+	effect.checked = true
+
+	return effect
+}
+
+// Records the effect of the proc being checked at one of its exits.
+func (c *checker) record(inst *Instruction, st *checkState) {
+	effect := c.current
+	if effect == nil {
+		return
+	}
+
+	if !effect.known {
+		effect.inputs = st.inputs
+		effect.outputs = append([]entry{}, st.stack...)
+		effect.known = true
+		return
+	}
+
+	seen := &checkState{stack: effect.outputs, inputs: effect.inputs}
+	now := st.clone()
+	align(seen, now)
+	if !sameShape(seen, now) {
+		c.errorf(inst.Token, "proc '%s' returns different stack shapes: %s and %s.", effect.name, formatShape(seen), formatShape(now))
+	}
+}
+
+func (c *checker) call(st *checkState, inst *Instruction) {
+	effect := c.procs[int(inst.Next)]
+	if !effect.checked && !effect.checking {
+		c.checkProc(int(inst.Next))
+	}
+
+	// A recursive call before any exit of the proc was seen has no known effect yet.
+	if !effect.known {
+		st.lost = true
+		return
+	}
+
+	if !c.need(st, inst.Token, effect.inputs) {
+		return
+	}
+
+	st.stack = st.stack[:len(st.stack) - effect.inputs]
+	st.stack = append(st.stack, effect.outputs...)
+}
+
+// Checks the instructions in [start, end) and returns the resulting state.
+func (c *checker) block(start, end int, st *checkState) *checkState {
+	for idx := start; idx < end; {
+		if st.dead || st.lost {
+			return st
+		}
+
+		inst := &c.program[idx]
+		token := inst.Token
+
+		if token.Equals("if", types.TokenTypeKeyword) || token.Equals("unless", types.TokenTypeKeyword) {
+			if !c.need(st, token, 1) {
+				return st
+			}
+
+			cond := st.pop()
+			truthy, falsy := c.branch(st, cond)
+			if token.Value == "unless" {
+				truthy, falsy = falsy, truthy
+			}
+
+			target := int(inst.Next)
+			other := &c.program[target - 1]
+
+			body := c.block(idx + 1, target - 1, truthy)
+			if other.Token.Equals("else", types.TokenTypeKeyword) {
+				falsy = c.block(target, int(other.Next) - 1, falsy)
+				idx = int(other.Next)
+			} else {
+				idx = target
+			}
+
+			st = c.merge(token, body, falsy)
+			continue
+		} else if token.Equals("while", types.TokenTypeKeyword) || token.Equals("until", types.TokenTypeKeyword) {
+			loopEnd := c.loops[idx]
+			do := idx + 1
+			for !c.program[do].Token.Equals("do", types.TokenTypeKeyword) || int(c.program[do].Next) != loopEnd + 1 {
+				do++
+			}
+
+			cond := c.block(idx + 1, do, st.clone())
+			if cond.dead || cond.lost {
+				return cond
+			}
+
+			if !c.need(cond, c.program[do].Token, 1) {
+				return cond
+			}
+			cond.pop()
+
+			c.loop(token, st, cond)
+			body := c.block(do + 1, loopEnd, cond.clone())
+			c.loop(token, cond, body)
+
+			st = cond
+			idx = loopEnd + 1
+			continue
+		} else if token.Equals("each", types.TokenTypeKeyword) {
+			if !c.need(st, token, 1) {
+				return st
+			}
+
+			container := c.expect(st, token, kindsOf(kindList, kindMap))
+			body := st.clone()
+			switch container.kind {
+			case kindMap:
+				body.push(kindString, kindAny)
+			case kindList:
+				body.push(kindAny)
+			default:
+				body.lost = true
+			}
+
+			loopEnd := int(inst.Next) - 1
+			body = c.block(idx + 1, loopEnd, body)
+			c.loop(token, st, body)
+
+			idx = loopEnd + 1
+			continue
+		} else if token.Equals("proc", types.TokenTypeKeyword) {
+			idx = int(inst.Next)
+			continue
+		} else if token.Equals("return", types.TokenTypeKeyword) {
+			c.record(inst, st)
+			st.dead = true
+			return st
+		} else if token.Equals("exit", types.TokenTypeKeyword) {
+			st.dead = true
+			return st
+		} else if inst.IsCall() {
+			c.call(st, inst)
+		} else {
+			c.step(st, token)
+		}
+
+		idx++
+	}
+
+	return st
+}
+
+var effects = map[string]struct {
+	inputs		[]kinds
+	outputs		[]kind
+}{
+	"puts": {[]kinds{kindsOf(kindString)}, nil},
+	".": {[]kinds{kindsNumber}, nil},
+	"int": {[]kinds{kindsScalar}, []kind{kindInt, kindBool}},
+	"float": {[]kinds{kindsScalar}, []kind{kindFloat, kindBool}},
+	"string": {[]kinds{kindsScalar}, []kind{kindString}},
+	"tostring": {[]kinds{kindsAny}, []kind{kindString}},
+	"store": {[]kinds{kindsAny, kindsOf(kindString)}, nil},
+	"!=": {[]kinds{kindsAny, kindsAny}, []kind{kindBool}},
+	"=": {[]kinds{kindsAny, kindsAny}, []kind{kindBool}},
+	"&&": {[]kinds{kindsAny, kindsAny}, []kind{kindBool}},
+	"||": {[]kinds{kindsAny, kindsAny}, []kind{kindBool}},
+	"~": {[]kinds{kindsOf(kindInt)}, []kind{kindInt}},
+	"&": {[]kinds{kindsOf(kindInt), kindsOf(kindInt)}, []kind{kindInt}},
+	"|": {[]kinds{kindsOf(kindInt), kindsOf(kindInt)}, []kind{kindInt}},
+	"^": {[]kinds{kindsOf(kindInt), kindsOf(kindInt)}, []kind{kindInt}},
+	"download": {[]kinds{kindsOf(kindString), kindsOf(kindPath)}, []kind{kindBool}},
+	"downloadwith": {[]kinds{kindsOf(kindString), kindsOf(kindPath), kindsOf(kindMap)}, []kind{kindBool}},
+	"downloadsum": {[]kinds{kindsOf(kindString), kindsOf(kindPath), kindsOf(kindString)}, []kind{kindBool}},
+	"move": {[]kinds{kindsOf(kindPath), kindsOf(kindPath)}, []kind{kindBool}},
+	"exist": {[]kinds{kindsOf(kindPath)}, []kind{kindBool}},
+	"touch": {[]kinds{kindsOf(kindPath)}, []kind{kindBool}},
+	"mkdir": {[]kinds{kindsOf(kindPath)}, []kind{kindBool}},
+	"rm": {[]kinds{kindsOf(kindPath)}, []kind{kindBool}},
+	"lsf": {[]kinds{kindsOf(kindPath)}, []kind{kindList}},
+	"lsd": {[]kinds{kindsOf(kindPath)}, []kind{kindList}},
+	"getf": {[]kinds{kindsOf(kindInt), kindsOf(kindPath)}, []kind{kindString}},
+	"getd": {[]kinds{kindsOf(kindInt), kindsOf(kindPath)}, []kind{kindString}},
+	"token": {[]kinds{kindsOf(kindString)}, []kind{kindPath}},
+	"absolute": {[]kinds{kindsOf(kindString)}, []kind{kindPath}},
+	"relative": {[]kinds{kindsOf(kindString)}, []kind{kindPath}},
+	"len": {[]kinds{kindsOf(kindList, kindMap, kindString)}, []kind{kindInt}},
+	"append": {[]kinds{kindsOf(kindList), kindsAny}, []kind{kindList}},
+	"join": {[]kinds{kindsOf(kindList), kindsOf(kindString)}, []kind{kindString}},
+	"split": {[]kinds{kindsOf(kindString), kindsOf(kindString)}, []kind{kindList}},
+	"set": {[]kinds{kindsOf(kindMap), kindsOf(kindString), kindsAny}, []kind{kindMap}},
+	"has": {[]kinds{kindsOf(kindMap), kindsOf(kindString)}, []kind{kindBool}},
+	"keys": {[]kinds{kindsOf(kindMap)}, []kind{kindList}},
+	"argc": {nil, []kind{kindInt}},
+	"args": {nil, []kind{kindList}},
+	"setenv": {[]kinds{kindsScalar, kindsOf(kindString)}, []kind{kindBool}},
+	"unsetenv": {[]kinds{kindsOf(kindString)}, []kind{kindBool}},
+	"env": {nil, []kind{kindMap}},
+	"loadenv": {[]kinds{kindsOf(kindPath)}, []kind{kindBool}},
+	"exec": {[]kinds{kindsOf(kindString, kindPath), kindsOf(kindString, kindList)}, []kind{kindString, kindString, kindInt}},
+	"execwith": {[]kinds{kindsOf(kindString, kindPath), kindsOf(kindString, kindList), kindsOf(kindMap)}, []kind{kindString, kindString, kindInt}},
+	"http": {[]kinds{kindsOf(kindString), kindsOf(kindString), kindsOf(kindMap), kindsOf(kindString)}, []kind{kindString, kindMap, kindInt}},
+}
+
+// Operators that push a different shape when they fail. Shapes are listed bottom to top.
+// A failure that pushes the same flag with extra values below it is tracked like a guarded result.
+// Other failures are only told apart by value at runtime, so the checker follows the success shape.
+var conditionalEffects = map[string]struct {
+	inputs		[]kinds
+	success		[]kind
+	failure		[]kind
+}{
+	// 'true' once copied, '<dst existed> false' otherwise.
+	"copy": {[]kinds{kindsOf(kindPath), kindsOf(kindPath)}, []kind{kindBool}, []kind{kindBool, kindBool}},
+	// '<dir count> <file count>' once unzipped, '-1' otherwise.
+	"unzip": {[]kinds{kindsOf(kindPath), kindsOf(kindPath)}, []kind{kindInt, kindInt}, []kind{kindInt}},
+}
+
+// Operators whose result is guarded by a trailing flag.
+var guardedEffects = map[string][]kinds{
+	"load": {kindsOf(kindString)},
+	"readfile": {kindsOf(kindPath)},
+	"nth": {kindsOf(kindList), kindsOf(kindInt)},
+	"get": {kindsOf(kindMap), kindsOf(kindString)},
+	"argv": {kindsOf(kindInt)},
+	"getenv": {kindsOf(kindString)},
+	"hash": {kindsOf(kindPath), kindsOf(kindString)},
+}
+
+// Kind of an arithmetic result. Ints stay ints unless a float takes part.
+func numberKind(a, b kind) kind {
+	if a == kindInt && b == kindInt {
+		return kindInt
+	} else if (a == kindFloat || b == kindFloat) && a != kindAny && b != kindAny {
+		return kindFloat
+	}
+
+	return kindAny
+}
+
+func (c *checker) step(st *checkState, token *types.Token) {
+	isOperator := token.Type == types.TokenTypeKeyword || token.Type == types.TokenTypeSymbol
+
+	if effect, ok := effects[token.Value]; ok && isOperator {
+		c.apply(st, token, effect.inputs, effect.outputs...)
+		return
+	}
+
+	if effect, ok := conditionalEffects[token.Value]; ok && isOperator {
+		c.apply(st, token, effect.inputs)
+		if st.lost {
+			return
+		}
+
+		extra := len(effect.failure) - len(effect.success)
+		if extra > 0 && len(effect.success) == 1 && effect.success[0] == kindBool && effect.failure[extra] == kindBool {
+			// The extra values only exist when the flag is false.
+			st.push(effect.failure[:extra]...)
+			st.stack = append(st.stack, entry{kind: kindBool, guard: extra, negated: true})
+		} else {
+			st.push(effect.success...)
+		}
+		return
+	}
+
+	if inputs, ok := guardedEffects[token.Value]; ok && isOperator {
+		c.apply(st, token, inputs)
+		if !st.lost {
+			st.pushGuarded(kindAny)
+			if token.Value == "readfile" || token.Value == "argv" || token.Value == "getenv" || token.Value == "hash" {
+				st.stack[len(st.stack) - 2].kind = kindString
+			}
+		}
+		return
+	}
+
+	switch {
+	case token.Equals("", types.TokenTypeNumber):
+		if _, ok := token.GetNumberValue(); ok {
+			st.push(kindInt)
+		} else {
+			st.push(kindFloat)
+		}
+	case token.Equals("", types.TokenTypeString):
+		st.push(kindString)
+	case token.Equals("", types.TokenTypePath):
+		st.push(kindPath)
+	case token.Equals("true", types.TokenTypeKeyword), token.Equals("false", types.TokenTypeKeyword):
+		st.push(kindBool)
+	case token.Equals("nop", types.TokenTypeKeyword),
+		token.Equals(scopeEnter, types.TokenTypeKeyword),
+		token.Equals(scopeLeave, types.TokenTypeKeyword):
+	case token.Equals(macroBind, types.TokenTypeKeyword):
+		params := token.Expansion.Params
+		if len(st.stack) < len(params) && !st.open {
+			c.errorf(token, "macro '%s' expects %d argument(s) (%s), but the stack has %d.", token.Expansion.Macro, len(params), strings.Join(params, " "), len(st.stack))
+			c.arity = append(c.arity, c.errors[len(c.errors) - 1])
+			st.lost = true
+		} else if c.need(st, token, len(params)) {
+			st.stack = st.stack[:len(st.stack) - len(params)]
+		}
+	case token.Equals("drop", types.TokenTypeKeyword):
+		if c.need(st, token, 1) {
+			st.pop()
+		}
+	case token.Equals("dup", types.TokenTypeKeyword):
+		c.shuffle(st, token, 1, 0, 0)
+	case token.Equals("swap", types.TokenTypeKeyword):
+		c.shuffle(st, token, 2, 1, 0)
+	case token.Equals("over", types.TokenTypeKeyword):
+		c.shuffle(st, token, 2, 0, 1, 0)
+	case token.Equals("2dup", types.TokenTypeKeyword):
+		c.shuffle(st, token, 2, 0, 1, 0, 1)
+	case token.Equals("2swap", types.TokenTypeKeyword):
+		c.shuffle(st, token, 4, 2, 3, 0, 1)
+	case token.Equals("!", types.TokenTypeSymbol):
+		if c.need(st, token, 1) {
+			top := st.pop()
+			st.stack = append(st.stack, entry{kind: kindBool, guard: top.guard, negated: !top.negated})
+		}
+	case token.Equals("+", types.TokenTypeSymbol):
+		c.add(st, token)
+	case token.Equals("-", types.TokenTypeSymbol),
+		token.Equals("*", types.TokenTypeSymbol),
+		token.Equals("/", types.TokenTypeSymbol),
+		token.Equals("%", types.TokenTypeSymbol):
+		if c.need(st, token, 2) {
+			b := c.expect(st, token, kindsNumber)
+			a := c.expect(st, token, kindsNumber)
+			st.push(numberKind(a.kind, b.kind))
+		}
+	case token.Equals("++", types.TokenTypeSymbol), token.Equals("--", types.TokenTypeSymbol):
+		if c.need(st, token, 1) {
+			a := c.expect(st, token, kindsNumber)
+			st.push(numberKind(a.kind, a.kind))
+		}
+	case token.Equals("<", types.TokenTypeSymbol),
+		token.Equals(">", types.TokenTypeSymbol),
+		token.Equals("<=", types.TokenTypeSymbol),
+		token.Equals(">=", types.TokenTypeSymbol):
+		if c.need(st, token, 2) {
+			b := c.expect(st, token, kindsOf(kindInt, kindFloat, kindString))
+			a := c.expect(st, token, kindsOf(kindInt, kindFloat, kindString))
+			if (a.kind == kindString) != (b.kind == kindString) && a.kind != kindAny && b.kind != kindAny {
+				c.errorf(token, "'%s' cannot compare %s and %s.", token.Value, a.kind, b.kind)
+			}
+			st.push(kindBool)
+		}
+	case token.Equals("concat", types.TokenTypeKeyword):
+		if c.need(st, token, 2) {
+			c.expect(st, token, kindsOf(kindString))
+			a := c.expect(st, token, kindsOf(kindPath, kindString))
+			st.push(a.kind)
+		}
+	case token.Equals("[", types.TokenTypeSymbol), token.Equals("{", types.TokenTypeSymbol):
+		st.marks = append(st.marks, len(st.stack))
+	case token.Equals("]", types.TokenTypeSymbol), token.Equals("}", types.TokenTypeSymbol):
+		c.collect(st, token)
+	default:
+		c.errorf(token, "'%s' has no known stack effect.", token.Value)
+		st.lost = true
+	}
+}
+
+// Rearranges the top count values. Picks are offsets from the bottom of those values.
+// Guard flags lose their guard once moved, as they no longer sit above their values.
+func (c *checker) shuffle(st *checkState, token *types.Token, count int, picks...int) {
+	if !c.need(st, token, count) {
+		return
+	}
+
+	top := st.stack[len(st.stack) - count:]
+	result := make([]entry, len(picks))
+	for idx, pick := range picks {
+		if idx == pick {
+			result[idx] = top[pick]
+		} else {
+			result[idx] = entry{kind: top[pick].kind}
+		}
+	}
+
+	st.stack = append(st.stack[:len(st.stack) - count], result...)
+}
+
+func (c *checker) add(st *checkState, token *types.Token) {
+	if !c.need(st, token, 2) {
+		return
+	}
+
+	b := st.pop()
+	a := st.pop()
+
+	switch a.kind {
+	case kindString:
+		if !kindsScalar.accepts(b.kind) {
+			c.errorf(token, "'+' cannot add %s to a string.", b.kind)
+		}
+		st.push(kindString)
+	case kindInt, kindFloat:
+		if !kindsNumber.accepts(b.kind) {
+			c.errorf(token, "'+' cannot add %s to a number.", b.kind)
+		}
+		st.push(numberKind(a.kind, b.kind))
+	case kindAny:
+		st.push(kindAny)
+	case kindPath:
+		c.errorf(token, "'+' expects a string or number, but got a path. use concat to extend paths.")
+		st.push(kindAny)
+	default:
+		c.errorf(token, "'+' expects a string or number, but got %s.", a.kind)
+		st.push(kindAny)
+	}
+}
+
+// Closes a list or map literal.
+func (c *checker) collect(st *checkState, token *types.Token) {
+	if len(st.marks) == 0 {
+		c.errorf(token, "'%s' closes a literal that was never opened.", token.Value)
+		st.lost = true
+		return
+	}
+
+	mark := st.marks[len(st.marks) - 1]
+	st.marks = st.marks[:len(st.marks) - 1]
+
+	if len(st.stack) < mark {
+		c.errorf(token, "'%s' literal consumed %d values from outside of it.", token.Value, mark - len(st.stack))
+		st.lost = true
+		return
+	}
+
+	items := st.stack[mark:]
+	st.stack = st.stack[:mark]
+
+	if token.Value == "]" {
+		st.push(kindList)
+		return
+	}
+
+	if len(items) % 2 != 0 {
+		c.errorf(token, "map literal holds %d values, expected key and value pairs.", len(items))
+	}
+
+	for idx := 0; idx + 1 < len(items); idx += 2 {
+		if !kindsOf(kindString).accepts(items[idx].kind) {
+			c.errorf(token, "map literal key %d is %s, not a string.", idx / 2, items[idx].kind)
+		}
+	}
+
+	st.push(kindMap)
+}
+
+// Summary of a failed check, returned once every problem has been printed.
+type CheckError struct {
+	Count		int
+}
+
+func (ce *CheckError) Error() string {
+	return fmt.Sprintf("check failed with %d problem(s).", ce.Count)
+}
+
+func (ce *CheckError) ExitCode() types.ExitCode {
+	return types.ExitCodeScript
+}
@@ -0,0 +1,178 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ktnuity/wet/internal/tools"
+)
+
+// Pops a url, a destination and an expected digest, and pushes whether the download succeeded.
+// Content that doesn't match the digest is a tool error rather than a false result, since the
+// script can't tell a wrong pin from a tampered file.
+func (ip *Interpreter) downloadsum() (bool, error) {
+	if ip.stack.Len() < 3 {
+		return ip.runtimeverr("failed to run step. downloadsum command failed. stack size is %d. 3 is required.\n", ip.stack.Len())
+	}
+
+	vDigest, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. downloadsum command failed. failed to get digest value: %v\n", err)
+	}
+
+	vDst, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. downloadsum command failed. failed to get destination value: %v\n", err)
+	}
+
+	vUrl, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. downloadsum command failed. failed to get url value: %v\n", err)
+	}
+
+	sDigest, ok := vDigest.String()
+	if !ok {
+		return ip.runtimeverr("failed to run step. downloadsum command failed. failed to get digest string.\n")
+	}
+
+	pDst, ok := vDst.Path()
+	if !ok {
+		return ip.runtimeverr("failed to run step. downloadsum command failed. failed to get destination path.\n")
+	}
+
+	sUrl, ok := vUrl.String()
+	if !ok {
+		return ip.runtimeverr("failed to run step. downloadsum command failed. failed to get url string.\n")
+	}
+
+	if _, err := tools.ParseDigest(sDigest); err != nil {
+		return ip.runtimeverr("failed to run step. downloadsum command failed. %v\n", err)
+	}
+
+	result := true
+	err = tools.ToolDownloadVerified(sUrl, pDst, sDigest)
+	if err != nil {
+		var checksumErr *tools.ChecksumError
+		if errors.As(err, &checksumErr) {
+			return ip.toolerr("failed to run step. downloadsum command failed. %v\n", err)
+		}
+
+		ip.runtimev("failed to use download tool: %v\n", err)
+		result = false
+	}
+
+	err = ip.bpush(result)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. downloadsum command failed. failure pushing value: %v", err)
+	}
+
+	return true, nil
+}
+
+// Pops a url, a destination and a map of headers, and pushes whether the download succeeded. The
+// headers are sent with every request of the download.
+func (ip *Interpreter) downloadwith() (bool, error) {
+	if ip.stack.Len() < 3 {
+		return ip.runtimeverr("failed to run step. downloadwith command failed. stack size is %d. 3 is required.\n", ip.stack.Len())
+	}
+
+	vHeaders, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. downloadwith command failed. failed to get headers: %v\n", err)
+	}
+
+	vDst, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. downloadwith command failed. failed to get destination value: %v\n", err)
+	}
+
+	vUrl, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. downloadwith command failed. failed to get url value: %v\n", err)
+	}
+
+	headers, ok, err := ip.headerMap("downloadwith", vHeaders)
+	if !ok || err != nil {
+		return ok, err
+	}
+
+	pDst, ok := vDst.Path()
+	if !ok {
+		return ip.runtimeverr("failed to run step. downloadwith command failed. failed to get destination path.\n")
+	}
+
+	sUrl, ok := vUrl.String()
+	if !ok {
+		return ip.runtimeverr("failed to run step. downloadwith command failed. failed to get url string.\n")
+	}
+
+	result := true
+	err = tools.ToolDownloadWith(sUrl, pDst, headers)
+	if err != nil {
+		ip.runtimev("failed to use download tool: %v\n", err)
+		result = false
+	}
+
+	err = ip.bpush(result)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. downloadwith command failed. failure pushing value: %v", err)
+	}
+
+	return true, nil
+}
+
+// Pops a resource and an algorithm name, and pushes the hex digest of the resource followed by true,
+// or only false when it can't be read.
+func (ip *Interpreter) hash() (bool, error) {
+	if ip.stack.Len() < 2 {
+		return ip.runtimeverr("failed to run step. hash command failed. stack size is %d. 2 is required.\n", ip.stack.Len())
+	}
+
+	vAlgorithm, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. hash command failed. failed to get algorithm value: %v\n", err)
+	}
+
+	vSrc, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. hash command failed. failed to get source value: %v\n", err)
+	}
+
+	sAlgorithm, ok := vAlgorithm.String()
+	if !ok {
+		return ip.runtimeverr("failed to run step. hash command failed. failed to get algorithm string.\n")
+	}
+
+	pSrc, ok := vSrc.Path()
+	if !ok {
+		return ip.runtimeverr("failed to run step. hash command failed. failed to get source path.\n")
+	}
+
+	if sAlgorithm != "sha256" && sAlgorithm != "sha512" {
+		return ip.runtimeverr("failed to run step. hash command failed. unsupported algorithm '%s'. expected sha256 or sha512.\n", sAlgorithm)
+	}
+
+	sum, err := tools.ToolHash(pSrc, sAlgorithm)
+	if err != nil {
+		ip.runtimev("failed to use hash tool: %v\n", err)
+		err = ip.bpush(false)
+		if err != nil {
+			return false, fmt.Errorf("failed to run step. hash command failed. failure pushing value: %v", err)
+		}
+
+		return true, nil
+	}
+
+	err = ip.spush(sum)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. hash command failed. failure pushing value: %v", err)
+	}
+	ip.runtimev("pushed %s\n", sum)
+
+	err = ip.bpush(true)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. hash command failed. failure pushing value: %v", err)
+	}
+
+	return true, nil
+}
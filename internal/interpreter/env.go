@@ -0,0 +1,177 @@
+package interpreter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ktnuity/wet/internal/tools"
+)
+
+// Pops a variable name and pushes its value followed by true, or only false when it isn't set.
+func (ip *Interpreter) getenv() (bool, error) {
+	if ip.stack.Len() < 1 {
+		return ip.runtimeverr("failed to run step. getenv command failed. stack is empty.\n")
+	}
+
+	vName, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. getenv command failed. failed to get name: %v\n", err)
+	}
+
+	name, ok := vName.String()
+	if !ok {
+		return ip.runtimeverr("failed to run step. getenv command failed. name is %s, not a string.\n", vName.Kind())
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		ip.runtimev("env '%s' not set\n", name)
+		err = ip.bpush(false)
+		if err != nil {
+			return false, fmt.Errorf("failed to run step. getenv command failed. failure pushing value: %v", err)
+		}
+
+		return true, nil
+	}
+
+	err = ip.spush(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. getenv command failed. failure pushing value: %v", err)
+	}
+	ip.runtimev("pushed %q\n", value)
+
+	err = ip.bpush(true)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. getenv command failed. failure pushing value: %v", err)
+	}
+
+	return true, nil
+}
+
+// Pops a variable name and the value below it, sets the variable and pushes whether that succeeded.
+// Any primary value is accepted and set as its string form.
+func (ip *Interpreter) setenv() (bool, error) {
+	if ip.stack.Len() < 2 {
+		return ip.runtimeverr("failed to run step. setenv command failed. stack size is %d. 2 is required.\n", ip.stack.Len())
+	}
+
+	vName, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. setenv command failed. failed to get name: %v\n", err)
+	}
+
+	name, ok := vName.String()
+	if !ok {
+		return ip.runtimeverr("failed to run step. setenv command failed. name is %s, not a string.\n", vName.Kind())
+	}
+
+	vValue, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. setenv command failed. failed to get value: %v\n", err)
+	}
+
+	if !vValue.IsPrimary() {
+		return ip.runtimeverr("failed to run step. setenv command failed. cannot set %s as a variable.\n", vValue.Kind())
+	}
+
+	result := true
+	err = os.Setenv(name, toString(vValue))
+	if err != nil {
+		ip.runtimev("failed to set env '%s': %v\n", name, err)
+		result = false
+	}
+
+	err = ip.bpush(result)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. setenv command failed. failure pushing value: %v", err)
+	}
+
+	return true, nil
+}
+
+// Pops a variable name, removes the variable and pushes whether that succeeded.
+func (ip *Interpreter) unsetenv() (bool, error) {
+	if ip.stack.Len() < 1 {
+		return ip.runtimeverr("failed to run step. unsetenv command failed. stack is empty.\n")
+	}
+
+	vName, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. unsetenv command failed. failed to get name: %v\n", err)
+	}
+
+	name, ok := vName.String()
+	if !ok {
+		return ip.runtimeverr("failed to run step. unsetenv command failed. name is %s, not a string.\n", vName.Kind())
+	}
+
+	result := true
+	err = os.Unsetenv(name)
+	if err != nil {
+		ip.runtimev("failed to unset env '%s': %v\n", name, err)
+		result = false
+	}
+
+	err = ip.bpush(result)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. unsetenv command failed. failure pushing value: %v", err)
+	}
+
+	return true, nil
+}
+
+// Pushes every variable as a map of names to values.
+func (ip *Interpreter) env() (bool, error) {
+	result := make(map[string]StackValue)
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		if name == "" {
+			continue
+		}
+
+		result[name] = StackString(value)
+	}
+
+	value := StackMap(result)
+	err := ip.push(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. env command failed. failure pushing value: %v", err)
+	}
+	ip.runtimev("pushed %d variables\n", len(result))
+
+	return true, nil
+}
+
+// Pops the path of a .env file, sets its variables and pushes whether the file was loaded.
+func (ip *Interpreter) loadenv() (bool, error) {
+	if ip.stack.Len() < 1 {
+		return ip.runtimeverr("failed to run step. loadenv command failed. stack is empty.\n")
+	}
+
+	vPath, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. loadenv command failed. failed to get path value: %v\n", err)
+	}
+
+	pPath, ok := vPath.Path()
+	if !ok {
+		return ip.runtimeverr("failed to run step. loadenv command failed. failed to get path.\n")
+	}
+
+	result := true
+	count, err := tools.ToolLoadEnv(pPath)
+	if err != nil {
+		ip.runtimev("failed to use loadenv tool: %v\n", err)
+		result = false
+	} else {
+		ip.runtimev("loaded %d variables\n", count)
+	}
+
+	err = ip.bpush(result)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. loadenv command failed. failure pushing value: %v", err)
+	}
+
+	return true, nil
+}
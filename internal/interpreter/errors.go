@@ -0,0 +1,133 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ktnuity/wet/internal/types"
+)
+
+type RuntimeErrorKind uint8
+const (
+	RuntimeErrorScript RuntimeErrorKind = iota
+	RuntimeErrorTool
+)
+
+type RuntimeError struct {
+	Kind		RuntimeErrorKind
+	Ip			int
+	Token		*types.Token
+	Operator	string
+	Expansion	*types.Expansion
+	Calls		[]Frame
+	Stack		[]StackValue
+	Message		string
+}
+
+func (re *RuntimeError) Error() string {
+	var pos types.Position
+	if re.Token != nil {
+		pos = re.Token.Pos
+	}
+
+	return types.NewSourceError(pos, "%s", re.Message).Error()
+}
+
+func (re *RuntimeError) ExitCode() types.ExitCode {
+	if re.Kind == RuntimeErrorTool {
+		return types.ExitCodeTool
+	}
+
+	return types.ExitCodeScript
+}
+
+// Report renders the error along with the interpreter state at the time of failure.
+func (re *RuntimeError) Report() string {
+	var sb strings.Builder
+	sb.WriteString(re.Error())
+	fmt.Fprintf(&sb, "\n  operator: %s (ip %d)", re.Operator, re.Ip)
+	if re.Expansion != nil {
+		fmt.Fprintf(&sb, "\n  trace: %s", re.Expansion.Trace())
+	}
+	if len(re.Calls) > 0 {
+		fmt.Fprintf(&sb, "\n  calls: %s", callTrace(re.Calls))
+	}
+	fmt.Fprintf(&sb, "\n  stack (%d):", len(re.Stack))
+
+	if len(re.Stack) == 0 {
+		sb.WriteString(" <empty>")
+	}
+
+	for idx := len(re.Stack) - 1; idx >= 0; idx-- {
+		fmt.Fprintf(&sb, "\n    %d: %s", len(re.Stack) - 1 - idx, re.Stack[idx].Format())
+	}
+
+	return sb.String()
+}
+
+type ExitRequest struct {
+	Pos			types.Position
+}
+
+func (er *ExitRequest) Error() string {
+	return fmt.Sprintf("%s: script called exit.", er.Pos.String())
+}
+
+func (er *ExitRequest) ExitCode() types.ExitCode {
+	return types.ExitCodeExit
+}
+
+func operatorName(token *types.Token) string {
+	if token == nil {
+		return "<nil>"
+	}
+
+	switch token.Type {
+	case types.TokenTypeNumber, types.TokenTypeString, types.TokenTypePath:
+		return "push"
+	default:
+		return token.Value
+	}
+}
+
+// Builds a RuntimeError for the current instruction. The stack snapshot is
+// taken as it was before the failing operator popped its operands.
+func (ip *Interpreter) runtimeError(kind RuntimeErrorKind, message string) *RuntimeError {
+	stack := make([]StackValue, 0, ip.stack.Len() + len(ip.popped))
+	stack = append(stack, ip.stack...)
+	for idx := len(ip.popped) - 1; idx >= 0; idx-- {
+		stack = append(stack, ip.popped[idx])
+	}
+
+	var token *types.Token
+	var expansion *types.Expansion
+	if ip.ip >= 0 && ip.ip < ip.eop {
+		token = ip.program[ip.ip].Token
+		expansion = ip.program[ip.ip].Expansion
+	}
+
+	return &RuntimeError{
+		Kind: kind,
+		Ip: ip.ip,
+		Token: token,
+		Operator: operatorName(token),
+		Expansion: expansion,
+		Calls: append([]Frame{}, ip.calls...),
+		Stack: stack,
+		Message: strings.TrimSpace(message),
+	}
+}
+
+// Renders the proc call stack innermost first, e.g. "fact <- fact <- init.wet:9".
+func callTrace(calls []Frame) string {
+	var sb strings.Builder
+	for idx := len(calls) - 1; idx >= 0; idx-- {
+		sb.WriteString(calls[idx].Proc)
+		sb.WriteString(" <- ")
+	}
+
+	site := calls[0].Site
+	fmt.Fprintf(&sb, "%s:%d", site.File, site.Line)
+
+	return sb.String()
+}
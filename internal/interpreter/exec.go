@@ -0,0 +1,146 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/ktnuity/wet/internal/tools"
+)
+
+// Pops the command and its arguments, runs it and pushes its stdout, stderr and exit code.
+// The output is only captured, never shown.
+func (ip *Interpreter) exec() (bool, error) {
+	if ip.stack.Len() < 2 {
+		return ip.runtimeverr("failed to run step. exec command failed. stack size is %d. 2 is required.\n", ip.stack.Len())
+	}
+
+	vArgs, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. exec command failed. failed to get arguments: %v\n", err)
+	}
+
+	return ip.runExec("exec", vArgs, tools.ExecOptions{})
+}
+
+// Like exec, with a map of options on top:
+//   - "cwd": resource location to run in.
+//   - "env": map of variables added to the environment.
+//   - "stream": whether the output is shown on the terminal while it is captured.
+func (ip *Interpreter) execwith() (bool, error) {
+	if ip.stack.Len() < 3 {
+		return ip.runtimeverr("failed to run step. execwith command failed. stack size is %d. 3 is required.\n", ip.stack.Len())
+	}
+
+	vOptions, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. execwith command failed. failed to get options: %v\n", err)
+	}
+
+	options, ok := vOptions.Map()
+	if !ok {
+		return ip.runtimeverr("failed to run step. execwith command failed. options is %s, not a map.\n", vOptions.Kind())
+	}
+
+	var opts tools.ExecOptions
+	for _, key := range sortedKeys(options) {
+		value := options[key]
+		switch key {
+		case "cwd":
+			path, ok := value.Path()
+			if !ok {
+				return ip.runtimeverr("failed to run step. execwith command failed. option 'cwd' is %s, not a path.\n", value.Kind())
+			}
+
+			opts.Dir = path
+		case "env":
+			env, ok := value.Map()
+			if !ok {
+				return ip.runtimeverr("failed to run step. execwith command failed. option 'env' is %s, not a map.\n", value.Kind())
+			}
+
+			for _, name := range sortedKeys(env) {
+				if !env[name].IsPrimary() {
+					return ip.runtimeverr("failed to run step. execwith command failed. env '%s' is %s, which cannot be set as a variable.\n", name, env[name].Kind())
+				}
+
+				opts.Env = append(opts.Env, name + "=" + toString(env[name]))
+			}
+		case "stream":
+			stream, ok := value.Bool()
+			if !ok {
+				return ip.runtimeverr("failed to run step. execwith command failed. option 'stream' is %s, not a bool.\n", value.Kind())
+			}
+
+			opts.Stream = stream
+		default:
+			return ip.runtimeverr("failed to run step. execwith command failed. unknown option '%s'. expected 'cwd', 'env' or 'stream'.\n", key)
+		}
+	}
+
+	vArgs, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. execwith command failed. failed to get arguments: %v\n", err)
+	}
+
+	return ip.runExec("execwith", vArgs, opts)
+}
+
+// Pops the command below vArgs and runs it. Arguments are a list of values or a string split like a
+// command line. A command that cannot be started gets exit code -1.
+func (ip *Interpreter) runExec(op string, vArgs StackValue, opts tools.ExecOptions) (bool, error) {
+	vName, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. %s command failed. failed to get command: %v\n", op, err)
+	}
+
+	name, ok := vName.String()
+	if !ok {
+		name, ok = vName.Path()
+	}
+
+	if !ok {
+		return ip.runtimeverr("failed to run step. %s command failed. command is %s, not a string or path.\n", op, vName.Kind())
+	}
+
+	var args []string
+	if list, ok := vArgs.List(); ok {
+		args = make([]string, len(list))
+		for idx, item := range list {
+			if !item.IsPrimary() && !item.IsPath() {
+				return ip.runtimeverr("failed to run step. %s command failed. argument %d is %s.\n", op, idx, item.Kind())
+			}
+
+			args[idx] = toString(item)
+		}
+	} else if line, ok := vArgs.String(); ok {
+		args, err = tools.SplitArgs(line)
+		if err != nil {
+			return ip.runtimeverr("failed to run step. %s command failed. failed to split arguments: %v\n", op, err)
+		}
+	} else {
+		return ip.runtimeverr("failed to run step. %s command failed. arguments is %s, not a list or string.\n", op, vArgs.Kind())
+	}
+
+	ip.runtimev("running %s %q\n", name, args)
+	result, err := tools.ToolExec(name, args, opts)
+	if err != nil {
+		ip.runtimev("failed to use exec tool: %v\n", err)
+	}
+	ip.runtimev("exit code %d\n", result.Code)
+
+	err = ip.spush(result.Stdout)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. %s command failed. failure pushing value: %v", op, err)
+	}
+
+	err = ip.spush(result.Stderr)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. %s command failed. failure pushing value: %v", op, err)
+	}
+
+	err = ip.ipush(result.Code)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. %s command failed. failure pushing value: %v", op, err)
+	}
+
+	return true, nil
+}
@@ -11,6 +11,13 @@ func SubmitFlags(flags types.WetFlag) {
 	argFlags = flags
 }
 
+// Arguments given to the script after '--', read by argc, argv and args.
+var scriptArgs []string
+
+func SubmitArgs(args []string) {
+	scriptArgs = args
+}
+
 func IsVerbose() bool {
 	return util.HasAnyBitFlags(argFlags, types.WetFlagVerbose)
 }
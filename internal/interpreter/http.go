@@ -0,0 +1,110 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/ktnuity/wet/internal/tools"
+)
+
+// Pops a method, a url, a map of headers and a body, sends the request and pushes the response body,
+// a map of its headers and its status. A request that gets no response pushes status -1.
+func (ip *Interpreter) http() (bool, error) {
+	if ip.stack.Len() < 4 {
+		return ip.runtimeverr("failed to run step. http command failed. stack size is %d. 4 is required.\n", ip.stack.Len())
+	}
+
+	vBody, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. http command failed. failed to get body: %v\n", err)
+	}
+
+	vHeaders, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. http command failed. failed to get headers: %v\n", err)
+	}
+
+	vUrl, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. http command failed. failed to get url value: %v\n", err)
+	}
+
+	vMethod, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. http command failed. failed to get method value: %v\n", err)
+	}
+
+	body, ok := vBody.String()
+	if !ok {
+		return ip.runtimeverr("failed to run step. http command failed. body is %s, not a string.\n", vBody.Kind())
+	}
+
+	headers, ok, err := ip.headerMap("http", vHeaders)
+	if !ok || err != nil {
+		return ok, err
+	}
+
+	url, ok := vUrl.String()
+	if !ok {
+		return ip.runtimeverr("failed to run step. http command failed. failed to get url string.\n")
+	}
+
+	method, ok := vMethod.String()
+	if !ok {
+		return ip.runtimeverr("failed to run step. http command failed. method is %s, not a string.\n", vMethod.Kind())
+	}
+
+	ip.runtimev("sending %s %s\n", method, url)
+	resp, err := tools.ToolHttp(tools.HttpRequest{
+		Method: method,
+		Url: url,
+		Headers: headers,
+		Body: body,
+	})
+	if err != nil {
+		ip.runtimev("failed to use http tool: %v\n", err)
+	}
+	ip.runtimev("status %d\n", resp.Status)
+
+	err = ip.spush(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. http command failed. failure pushing value: %v", err)
+	}
+
+	result := make(map[string]StackValue, len(resp.Headers))
+	for name, value := range resp.Headers {
+		result[name] = StackString(value)
+	}
+
+	err = ip.push(StackMap(result))
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. http command failed. failure pushing value: %v", err)
+	}
+
+	err = ip.ipush(resp.Status)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. http command failed. failure pushing value: %v", err)
+	}
+
+	return true, nil
+}
+
+// Reads a map of header names to values. Any primary value is sent as its string form.
+func (ip *Interpreter) headerMap(op string, vHeaders StackValue) (map[string]string, bool, error) {
+	values, ok := vHeaders.Map()
+	if !ok {
+		status, err := ip.runtimeverr("failed to run step. %s command failed. headers is %s, not a map.\n", op, vHeaders.Kind())
+		return nil, status, err
+	}
+
+	headers := make(map[string]string, len(values))
+	for _, name := range sortedKeys(values) {
+		if !values[name].IsPrimary() {
+			status, err := ip.runtimeverr("failed to run step. %s command failed. header '%s' is %s, which cannot be sent.\n", op, name, values[name].Kind())
+			return nil, status, err
+		}
+
+		headers[name] = toString(values[name])
+	}
+
+	return headers, true, nil
+}
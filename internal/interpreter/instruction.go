@@ -2,13 +2,17 @@ package interpreter
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 
+	"github.com/ktnuity/wet/internal/tokenizer"
 	"github.com/ktnuity/wet/internal/types"
 	"github.com/ktnuity/wet/internal/util"
 )
 
 type Instruction struct {
 	Token		*types.Token
+	Expansion	*types.Expansion
 	Next		int64
 	Mode		uint8
 }
@@ -19,9 +23,22 @@ const (
 	DoModeUntil DoMode = 1
 )
 
+type EndMode = uint8
+const (
+	EndModeBlock EndMode = 0
+	EndModeReturn EndMode = 1
+	EndModeEach EndMode = 2
+)
+
+// Calls are plain words resolved to a proc body. Next holds the first body instruction.
+func (inst *Instruction) IsCall() bool {
+	return inst.Token.Type == types.TokenTypeNone && inst.Next != -1
+}
+
 func CreateInstruction(token *types.Token) Instruction {
 	return Instruction{
 		Token: token,
+		Expansion: token.Expansion,
 		Next: -1,
 	}
 }
@@ -40,10 +57,23 @@ func CreateInstructions(tokens []types.Token) []Instruction {
 	return result
 }
 
-func scanMacros(tokens []types.Token) ([]types.Token, map[string][]types.Token, error) {
+// Delimit the parameter list of a macro.
+const (
+	paramsOpen = "("
+	paramsClose = ")"
+)
+
+// A macro body, and the parameters it binds from the stack when expanded.
+type macro struct {
+	pos		types.Position
+	params	[]string
+	body	[]types.Token
+}
+
+func scanMacros(tokens []types.Token) ([]types.Token, map[string]macro, error) {
 	result := make([]types.Token, 0, len(tokens))
 
-	resultMap := make(map[string][]types.Token)
+	resultMap := make(map[string]macro)
 
 	for idx := 0; idx < len(tokens); idx++ {
 		token := &tokens[idx]
@@ -51,17 +81,30 @@ func scanMacros(tokens []types.Token) ([]types.Token, map[string][]types.Token,
 		if token.Value == "macro" {
 			idx++
 			if idx >= len(tokens) {
-				return nil, nil, fmt.Errorf("failed to detect macro name at index %d. reached eof early.", idx)
+				return nil, nil, token.Errorf("failed to detect macro name. reached eof early.")
 			}
 
-			macroName := tokens[idx].Value
+			nameToken := &tokens[idx]
+			macroName := nameToken.Value
+			if other, exists := resultMap[macroName]; exists {
+				if err := macroConflict(nameToken, other); err != nil {
+					return nil, nil, err
+				}
+			}
 
 			idx++
-			macroStart := idx
 			if idx >= len(tokens) {
-				return nil, nil, fmt.Errorf("failed to detect macro start at index %d for macro name '%s'. reached eof early.", idx, macroName)
+				return nil, nil, token.Errorf("failed to detect macro start for macro name '%s'. reached eof early.", macroName)
+			}
+
+			params, bodyStart, err := scanParams(tokens, idx, macroName)
+			if err != nil {
+				return nil, nil, err
 			}
 
+			idx = bodyStart
+			macroStart := idx
+
 			end := -1
 			scopes := 0
 
@@ -75,17 +118,19 @@ func scanMacros(tokens []types.Token) ([]types.Token, map[string][]types.Token,
 					} else {
 						scopes--
 					}
-				} else if endToken.Value == "if" || endToken.Value == "while" || endToken.Value == "unless" || endToken.Value == "until" {
+				} else if endToken.Value == "if" || endToken.Value == "while" || endToken.Value == "unless" || endToken.Value == "until" || endToken.Value == "each" {
 					scopes++
 				} else if endToken.Equals("macro", types.TokenTypeNone) {
-					return nil, nil, fmt.Errorf("failed to parse macro body. detected unsupported nested macro at index %d for macro name '%s'.", idx, macroName)
+					return nil, nil, endToken.Errorf("failed to parse macro body. detected unsupported nested macro for macro name '%s'.", macroName)
+				} else if endToken.Equals("proc", types.TokenTypeKeyword) {
+					return nil, nil, endToken.Errorf("failed to parse macro body. detected unsupported proc definition in macro name '%s'.", macroName)
 				}
 
 				idx++
 			}
 
 			if end == -1 {
-				return nil, nil, fmt.Errorf("failed to find end for macro name '%s' definition at index %d.", macroName, idx)
+				return nil, nil, token.Errorf("failed to find end for macro name '%s' definition.", macroName)
 			}
 
 			body := make([]types.Token, end - macroStart)
@@ -94,7 +139,11 @@ func scanMacros(tokens []types.Token) ([]types.Token, map[string][]types.Token,
 				body[itemIndex - macroStart] = tokens[itemIndex]
 			}
 
-			resultMap[macroName] = body
+			resultMap[macroName] = macro{
+				pos: nameToken.Pos,
+				params: params,
+				body: body,
+			}
 
 			idx = end
 		} else {
@@ -105,15 +154,85 @@ func scanMacros(tokens []types.Token) ([]types.Token, map[string][]types.Token,
 	return result, resultMap, nil
 }
 
-func expandMacros(tokens []types.Token) ([]types.Token, error) {
+// A script may redefine its own macros, but not those of the std. The error is placed on the script's definition.
+func macroConflict(name *types.Token, other macro) error {
+	nameStd := name.Pos.Std
+	otherStd := other.pos.Std
+	if nameStd == otherStd {
+		return nil
+	}
+
+	if nameStd {
+		return types.NewSourceError(other.pos, "failed to define macro '%s'. the name is already used by the std macro at %s.", name.Value, name.Pos)
+	}
+
+	return name.Errorf("failed to define macro '%s'. the name is already used by the std macro at %s.", name.Value, other.pos)
+}
+
+// Parameters are listed between parentheses right after the macro name, as in 'macro fetch ( url dst )'.
+// Without them, the macro takes no parameters and its body starts right after the name. Returns the
+// parameters and where the body starts.
+func scanParams(tokens []types.Token, start int, macroName string) ([]string, int, error) {
+	if !isWord(&tokens[start], paramsOpen) {
+		return nil, start, nil
+	}
+
+	params := make([]string, 0, 4)
+	for idx := start + 1; idx < len(tokens); idx++ {
+		param := &tokens[idx]
+
+		if isWord(param, paramsClose) {
+			return params, idx + 1, nil
+		} else if param.Type != types.TokenTypeNone || param.Value == paramsOpen {
+			return nil, 0, param.Errorf("failed to parse macro parameters. '%s' is not a valid parameter name for macro name '%s'.", param.Value, macroName)
+		} else if param.Value == macroName {
+			return nil, 0, param.Errorf("failed to parse macro parameters. parameter '%s' shadows the macro name.", param.Value)
+		} else if param.Value[0] == '.' {
+			return nil, 0, param.Errorf("failed to parse macro parameters. parameter '%s' cannot be global.", param.Value)
+		} else if slices.Contains(params, param.Value) {
+			return nil, 0, param.Errorf("failed to parse macro parameters. parameter '%s' is already defined for macro name '%s'.", param.Value, macroName)
+		}
+
+		params = append(params, param.Value)
+	}
+
+	return nil, 0, tokens[start].Errorf("failed to parse macro parameters. missing closing '%s' for macro name '%s'.", paramsClose, macroName)
+}
+
+// Token.Equals never matches plain words by value, so they are compared here.
+func isWord(token *types.Token, value string) bool {
+	return token.Type == types.TokenTypeNone && token.Value == value
+}
+
+// Parameters are bound into the macro scope by macroBind. Every use of a parameter in the body
+// loads it back, so a parameter shadows any macro or proc of the same name.
+func expandParam(item types.Token) []types.Token {
+	name := item
+	name.Value = "\"" + item.Value + "\""
+	name.Type = types.TokenTypeString
+
+	load := item
+	load.Value = "load"
+	load.Type = types.TokenTypeKeyword
+
+	drop := item
+	drop.Value = "drop"
+	drop.Type = types.TokenTypeKeyword
+
+	return []types.Token{name, load, drop}
+}
+
+// Returns the expanded tokens along with the names of every defined macro.
+func expandMacros(tokens []types.Token) ([]types.Token, []string, error) {
 	tokens, macroMap, err := scanMacros(tokens)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to expand macros. scan macros failed: %v", err)
+		return nil, nil, fmt.Errorf("failed to expand macros. scan macros failed: %w", err)
 	}
 
 	deadLimit := 12
 	dirty := true
+	var last *types.Token
 
 	for dirty && deadLimit > 0 {
 		deadLimit--
@@ -124,12 +243,55 @@ func expandMacros(tokens []types.Token) ([]types.Token, error) {
 		for idx := range len(tokens) {
 			token := &tokens[idx]
 
-			body, exists := macroMap[token.Value]
+			m, exists := macroMap[token.Value]
+			if exists && idx > 0 && tokens[idx - 1].Equals("proc", types.TokenTypeKeyword) {
+				return nil, nil, token.Errorf("failed to expand macros. proc name '%s' is already defined as a macro.", token.Value)
+			}
+
 			if exists {
-				for _, item := range body {
-					newTokens = append(newTokens, item)
+				expansion := &types.Expansion{
+					Macro: token.Value,
+					Params: m.params,
+					Pos: token.Pos,
+					Parent: token.Expansion,
+				}
+
+				// Only macros with parameters get a scope to bind them in. Other bodies run in the
+				// scope of their caller, so a store inside them is seen by the caller.
+				scoped := len(m.params) > 0
+				if scoped {
+					newTokens = append(newTokens, types.Token{
+						Value: scopeEnter,
+						Type: types.TokenTypeKeyword,
+						Pos: token.Pos,
+						Expansion: expansion,
+					}, types.Token{
+						Value: macroBind,
+						Type: types.TokenTypeKeyword,
+						Pos: token.Pos,
+						Expansion: expansion,
+					})
+				}
+
+				for _, item := range m.body {
+					item.Expansion = expansion
+					if item.Type == types.TokenTypeNone && slices.Contains(m.params, item.Value) {
+						newTokens = append(newTokens, expandParam(item)...)
+					} else {
+						newTokens = append(newTokens, item)
+					}
+				}
+
+				if scoped {
+					newTokens = append(newTokens, types.Token{
+						Value: scopeLeave,
+						Type: types.TokenTypeKeyword,
+						Pos: token.Pos,
+						Expansion: expansion,
+					})
 				}
 				dirty = true
+				last = token
 				continue
 			} else {
 				newTokens = append(newTokens, *token)
@@ -140,38 +302,67 @@ func expandMacros(tokens []types.Token) ([]types.Token, error) {
 	}
 
 	if dirty {
-		return nil, fmt.Errorf("failed to expand macro. dead limit reached.")
+		return nil, nil, last.Errorf("failed to expand macro '%s'. dead limit reached.", last.Value)
 	}
 
-	return tokens, nil
+	return tokens, slices.Collect(maps.Keys(macroMap)), nil
 }
 
 func ProcessTokens(tokens []types.Token) ([]Instruction, error) {
-	tokens, err := expandMacros(tokens)
-	
+	tokens, macros, err := expandMacros(tokens)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to process tokens: %w", err)
+	}
+
+	instructions := CreateInstructions(tokens)
+
+	procs, err := scanProcs(instructions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to process tokens: %v.", err)
+		return nil, fmt.Errorf("failed to process tokens: %w", err)
 	}
 
 	ipStack := &util.Stack[int64]{}
+	var procIp int64 = -1
 
-	instructions := CreateInstructions(tokens)
 	for idx := range int64(len(instructions)) {
 		instruction := &instructions[idx]
 
-		if instruction.Token.Equals("if", types.TokenTypeKeyword) {
+		if instruction.Token.Equals("proc", types.TokenTypeKeyword) {
+			if procIp != -1 {
+				return nil, instruction.Token.Errorf("failed to process instruction. nested proc definitions are not supported.")
+			}
+
+			procIp = idx
+			ipStack.Push(idx)
+		} else if instruction.Token.Equals("return", types.TokenTypeKeyword) {
+			if procIp == -1 {
+				return nil, instruction.Token.Errorf("failed to process instruction. return reached outside of proc.")
+			}
+		} else if instruction.Token.Type == types.TokenTypeNone {
+			if idx > 0 && instructions[idx - 1].Token.Equals("proc", types.TokenTypeKeyword) {
+				continue
+			}
+
+			target, ok := procs[instruction.Token.Value]
+			if !ok {
+				return nil, unknownIdentifier(instruction.Token, macros, procs)
+			}
+
+			instruction.Next = target
+		} else if instruction.Token.Equals("if", types.TokenTypeKeyword) {
 			ipStack.Push(idx)
 		} else if instruction.Token.Equals("unless", types.TokenTypeKeyword) {
 			ipStack.Push(idx)
 		} else if instruction.Token.Equals("else", types.TokenTypeKeyword) {
 			ip, ok := ipStack.Pop()
 			if !ok {
-				return nil, fmt.Errorf("failed to process instruction. else reached without ip-stack at index %d", idx)
+				return nil, instruction.Token.Errorf("failed to process instruction. else reached without ip-stack.")
 			}
 
 			other := &instructions[ip]
 			if !other.Token.Equals("if", types.TokenTypeKeyword) && !other.Token.Equals("unless", types.TokenTypeKeyword) {
-				return nil, fmt.Errorf("failed to process instruction. else reached without if at index %d", idx)
+				return nil, instruction.Token.Errorf("failed to process instruction. else reached without if.")
 			}
 
 			other.Next = idx + 1
@@ -179,15 +370,17 @@ func ProcessTokens(tokens []types.Token) ([]Instruction, error) {
 			ipStack.Push(idx)
 		} else if instruction.Token.Equals("while", types.TokenTypeKeyword) || instruction.Token.Equals("until", types.TokenTypeKeyword) {
 			ipStack.Push(idx)
+		} else if instruction.Token.Equals("each", types.TokenTypeKeyword) {
+			ipStack.Push(idx)
 		} else if instruction.Token.Equals("do", types.TokenTypeKeyword) {
 			ip, ok := ipStack.Pop()
 			if !ok {
-				return nil, fmt.Errorf("failed to process instruction. do reached without ip-stack at index %d", idx)
+				return nil, instruction.Token.Errorf("failed to process instruction. do reached without ip-stack.")
 			}
 
 			other := &instructions[ip]
 			if !other.Token.Equals("while", types.TokenTypeKeyword) && !other.Token.Equals("until", types.TokenTypeKeyword){
-				return nil, fmt.Errorf("failed to process instruction. do reached without while or until at index %d", idx)
+				return nil, instruction.Token.Errorf("failed to process instruction. do reached without while or until.")
 			}
 
 			ipStack.Push(idx);
@@ -200,7 +393,7 @@ func ProcessTokens(tokens []types.Token) ([]Instruction, error) {
 		} else if instruction.Token.Equals("end", types.TokenTypeKeyword) {
 			ip, ok := ipStack.Pop()
 			if !ok {
-				return nil, fmt.Errorf("failed to process instruction. end reached without ip-stack at index %d", idx)
+				return nil, instruction.Token.Errorf("failed to process instruction. end reached without ip-stack.")
 			}
 
 			other := &instructions[ip]
@@ -212,15 +405,68 @@ func ProcessTokens(tokens []types.Token) ([]Instruction, error) {
 				doIp := other.Next
 				other.Next = idx + 1
 				instruction.Next = doIp
+			} else if other.Token.Equals("each", types.TokenTypeKeyword) {
+				other.Next = idx + 1
+				instruction.Next = ip
+				instruction.Mode = EndModeEach
+			} else if other.Token.Equals("proc", types.TokenTypeKeyword) {
+				other.Next = idx + 1
+				instruction.Mode = EndModeReturn
+				procIp = -1
 			} else {
-				return nil, fmt.Errorf("failed to process instruction. end reached without if or else at index %d", idx)
+				return nil, instruction.Token.Errorf("failed to process instruction. end reached without if or else.")
 			}
 		}
 	}
 
 	if ipStack.Len() > 0 {
-		return nil, fmt.Errorf("failed to process instruction. process ended with ip-stack size %d. empty required.", ipStack.Len())
+		ip, _ := ipStack.Peek()
+		return nil, instructions[ip].Token.Errorf("failed to process instruction. block is never closed. process ended with ip-stack size %d.", ipStack.Len())
 	}
 
 	return instructions, nil
 }
+
+// Words left after macro expansion must name a proc. Anything else is reported
+// with the closest keyword, macro or proc name as a suggestion.
+func unknownIdentifier(token *types.Token, macros []string, procs map[string]int64) error {
+	candidates := tokenizer.Keywords()
+	candidates = append(candidates, macros...)
+	candidates = append(candidates, slices.Collect(maps.Keys(procs))...)
+	slices.Sort(candidates)
+
+	if suggestion, ok := util.Suggest(token.Value, candidates); ok {
+		return token.Errorf("unknown identifier '%s'. did you mean '%s'?", token.Value, suggestion)
+	}
+
+	return token.Errorf("unknown identifier '%s'.", token.Value)
+}
+
+// Collects proc names up front so calls may appear before the definition, including recursive calls.
+// Maps each name to the first instruction of its body.
+func scanProcs(instructions []Instruction) (map[string]int64, error) {
+	result := make(map[string]int64)
+
+	for idx := range int64(len(instructions)) {
+		if !instructions[idx].Token.Equals("proc", types.TokenTypeKeyword) {
+			continue
+		}
+
+		if idx + 1 >= int64(len(instructions)) {
+			return nil, instructions[idx].Token.Errorf("failed to detect proc name. reached eof early.")
+		}
+
+		name := instructions[idx + 1].Token
+		if name.Type != types.TokenTypeNone {
+			return nil, name.Errorf("failed to define proc. '%s' is not a valid proc name.", name.Value)
+		}
+
+		if _, exists := result[name.Value]; exists {
+			return nil, name.Errorf("failed to define proc. proc '%s' is already defined.", name.Value)
+		}
+
+		result[name.Value] = idx + 2
+	}
+
+	return result, nil
+}
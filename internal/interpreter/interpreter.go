@@ -1,7 +1,10 @@
 package interpreter
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -14,19 +17,92 @@ import (
 type StackValue struct {
 	tstring *string
 	tint *int
+	tfloat *float64
+	tbool *bool
 	tpath *string
+	tlist *[]StackValue
+	tmap *map[string]StackValue
 }
 
 func StackString(val string) StackValue {return StackValue{tstring: &val}}
 func StackInt(val int) StackValue {return StackValue{tint: &val}}
+func StackFloat(val float64) StackValue {return StackValue{tfloat: &val}}
+func StackBool(val bool) StackValue {return StackValue{tbool: &val}}
 func StackPath(val string) StackValue {return StackValue{tpath: &val}}
+func StackList(val []StackValue) StackValue {return StackValue{tlist: &val}}
+func StackMap(val map[string]StackValue) StackValue {return StackValue{tmap: &val}}
 
 func (sv StackValue) IsString() bool { return sv.tstring != nil }
 func (sv StackValue) IsInt() bool { return sv.tint != nil }
+func (sv StackValue) IsFloat() bool { return sv.tfloat != nil }
+func (sv StackValue) IsBool() bool { return sv.tbool != nil }
 func (sv StackValue) IsPath() bool { return sv.tpath != nil }
+func (sv StackValue) IsList() bool { return sv.tlist != nil }
+func (sv StackValue) IsMap() bool { return sv.tmap != nil }
+
+func (sv StackValue) IsNumber() bool { return sv.tint != nil || sv.tfloat != nil }
+func (sv StackValue) IsPrimary() bool { return sv.tstring != nil || sv.IsNumber() || sv.tbool != nil }
+func (sv StackValue) IsAny() bool { return sv.IsPrimary() || sv.tpath != nil || sv.tlist != nil || sv.tmap != nil }
+
+func (sv StackValue) Format() string {
+	if s, ok := sv.String(); ok {
+		return strconv.Quote(s)
+	} else if n, ok := sv.Int(); ok {
+		return strconv.Itoa(n)
+	} else if f, ok := sv.Float(); ok {
+		return tools.ToolToStringFloat(f)
+	} else if b, ok := sv.Bool(); ok {
+		return tools.ToolToStringBool(b)
+	} else if p, ok := sv.Path(); ok {
+		return types.EscapePath(p)
+	} else if l, ok := sv.List(); ok {
+		return formatList(l)
+	} else if m, ok := sv.Map(); ok {
+		return formatMap(m)
+	}
+
+	return "<none>"
+}
+
+// Kind names the type of the value for error messages.
+func (sv StackValue) Kind() string {
+	if sv.IsString() {
+		return "string"
+	} else if sv.IsInt() {
+		return "int"
+	} else if sv.IsFloat() {
+		return "float"
+	} else if sv.IsBool() {
+		return "bool"
+	} else if sv.IsPath() {
+		return "path"
+	} else if sv.IsList() {
+		return "list"
+	} else if sv.IsMap() {
+		return "map"
+	}
+
+	return "none"
+}
+
+// Truthy is used by conditions. Empty strings and zero numbers are false.
+func (sv StackValue) Truthy() bool {
+	if b, ok := sv.Bool(); ok {
+		return b
+	} else if s, ok := sv.String(); ok {
+		return len(s) > 0
+	} else if n, ok := sv.Int(); ok {
+		return n != 0
+	} else if f, ok := sv.Float(); ok {
+		return f != 0
+	} else if l, ok := sv.List(); ok {
+		return len(l) > 0
+	} else if m, ok := sv.Map(); ok {
+		return len(m) > 0
+	}
 
-func (sv StackValue) IsPrimary() bool { return sv.tstring != nil || sv.tint != nil }
-func (sv StackValue) IsAny() bool { return sv.tstring != nil || sv.tint != nil || sv.tpath != nil }
+	return false
+}
 
 func (sv StackValue) String() (string, bool) {
 	if sv.tstring != nil {
@@ -44,6 +120,33 @@ func (sv StackValue) Int() (int, bool) {
 	return 0, false
 }
 
+func (sv StackValue) Float() (float64, bool) {
+	if sv.tfloat != nil {
+		return *sv.tfloat, true
+	}
+
+	return 0, false
+}
+
+// Number returns ints and floats alike as a float.
+func (sv StackValue) Number() (float64, bool) {
+	if sv.tint != nil {
+		return float64(*sv.tint), true
+	} else if sv.tfloat != nil {
+		return *sv.tfloat, true
+	}
+
+	return 0, false
+}
+
+func (sv StackValue) Bool() (bool, bool) {
+	if sv.tbool != nil {
+		return *sv.tbool, true
+	}
+
+	return false, false
+}
+
 func (sv StackValue) Path() (string, bool) {
 	if sv.tpath != nil {
 		return *sv.tpath, true
@@ -52,10 +155,42 @@ func (sv StackValue) Path() (string, bool) {
 	return "", false
 }
 
+func (sv StackValue) List() ([]StackValue, bool) {
+	if sv.tlist != nil {
+		return *sv.tlist, true
+	}
+
+	return nil, false
+}
+
+func (sv StackValue) Map() (map[string]StackValue, bool) {
+	if sv.tmap != nil {
+		return *sv.tmap, true
+	}
+
+	return nil, false
+}
+
+// Frame is pushed for every proc call. Scopes holds the locals of the call.
+type Frame struct {
+	Proc		string
+	Site		types.Position
+	Return		int
+	Scopes		[]Scope
+	Iters		int
+}
+
+const maxCallDepth = 4096
+
 type Interpreter struct {
 	stack		util.Stack[StackValue]
+	popped		[]StackValue
 	program		[]Instruction
-	memory		map[string]StackValue
+	memory		Scope
+	scopes		[]Scope
+	calls		util.Stack[Frame]
+	marks		util.Stack[int]
+	iters		util.Stack[iterator]
 	ip			int
 	eop			int
 }
@@ -65,13 +200,22 @@ func CreateNew(tokens []types.Token) (*Interpreter, error) {
 
 	program, err := ProcessTokens(tokens)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create interpreter: %v", err)
+		return nil, fmt.Errorf("failed to create interpreter: %w", err)
+	}
+
+	// Missing macro arguments are reported before anything runs.
+	if errs := CheckArity(program); len(errs) > 0 {
+		return nil, fmt.Errorf("failed to create interpreter: %w", errs[0])
 	}
 
+	memory := make(Scope)
+
 	return &Interpreter{
 		stack: stack,
 		program: program,
-		memory: make(map[string]StackValue),
+		memory: memory,
+		scopes: []Scope{memory},
+		calls: util.Stack[Frame]{},
 		ip: 0,
 		eop: int(len(program)),
 	}, nil
@@ -85,7 +229,7 @@ func (ip *Interpreter) Run() (bool, error) {
 	for ip.ip < ip.eop {
 		status, err := ip.Step()
 		if err != nil {
-			return false, fmt.Errorf("failed to run interpreter. interpreter step failed: %v", err)
+			return false, ip.wrapError(err)
 		}
 
 		if !status {
@@ -115,7 +259,24 @@ func (ip *Interpreter) runtimev(format string, args...any) bool {
 }
 
 func (ip *Interpreter) runtimeverr(format string, args...any) (bool, error) {
-	return ip.runtimev(format, args...), nil
+	ip.runtimev(format, args...)
+	return false, ip.runtimeError(RuntimeErrorScript, fmt.Sprintf(format, args...))
+}
+
+func (ip *Interpreter) toolerr(format string, args...any) (bool, error) {
+	ip.runtimev(format, args...)
+	return false, ip.runtimeError(RuntimeErrorTool, fmt.Sprintf(format, args...))
+}
+
+// Turns any step failure into a RuntimeError at the current instruction.
+func (ip *Interpreter) wrapError(err error) error {
+	var re *RuntimeError
+	var er *ExitRequest
+	if errors.As(err, &re) || errors.As(err, &er) {
+		return err
+	}
+
+	return ip.runtimeError(RuntimeErrorScript, err.Error())
 }
 
 func (ip *Interpreter) Step() (bool, error) {
@@ -129,6 +290,7 @@ func (ip *Interpreter) Step() (bool, error) {
 
 	inst := &ip.program[ip.ip]
 	token := inst.Token
+	ip.popped = ip.popped[:0]
 
 	if token == nil {
 		return ip.runtimeverr("failed to run step. token at index %d is nil\n", ip.ip)
@@ -142,15 +304,20 @@ func (ip *Interpreter) Step() (bool, error) {
 	}
 
 	if token.Equals("", types.TokenTypeNumber) {
-		num, ok := token.GetNumberValue()
-		if !ok {
-			return ip.runtimeverr("failed to run step. token at index %d is has invalid number\n", ip.ip)
-		}
-
-		ip.runtimev("pushing %d\n", num)
-		err := ip.ipush(num)
-		if err != nil {
-			return false, fmt.Errorf("failed to run step. number push operator failed. failure pushing value: %v", err)
+		if num, ok := token.GetNumberValue(); ok {
+			ip.runtimev("pushing %d\n", num)
+			err := ip.ipush(num)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. number push operator failed. failure pushing value: %v", err)
+			}
+		} else if num, ok := token.GetFloatValue(); ok {
+			ip.runtimev("pushing %s\n", tools.ToolToStringFloat(num))
+			err := ip.fpush(num)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. number push operator failed. failure pushing value: %v", err)
+			}
+		} else {
+			return ip.runtimeverr("failed to run step. token '%s' is an invalid number.\n", token.Value)
 		}
 	} else if token.Equals("", types.TokenTypeString) {
 		str, ok := token.GetStringValue()
@@ -169,11 +336,18 @@ func (ip *Interpreter) Step() (bool, error) {
 			return ip.runtimeverr("failed to run step. token at index %d has invalid path\n", ip.ip)
 		}
 
-		ip.runtimev("pushing path(%s)\n", str)
+		ip.runtimev("pushing path(%s)\n", types.EscapePath(str))
 		err := ip.ppush(str)
 		if err != nil {
 			return false, fmt.Errorf("failed to run step. path push operator failed. failure pushing value: %v", err)
 		}
+	} else if token.Equals("true", types.TokenTypeKeyword) || token.Equals("false", types.TokenTypeKeyword) {
+		value := token.Value == "true"
+		ip.runtimev("pushing %t\n", value)
+		err := ip.bpush(value)
+		if err != nil {
+			return false, fmt.Errorf("failed to run step. bool push operator failed. failure pushing value: %v", err)
+		}
 	} else if token.Equals(".", types.TokenTypeSymbol) {
 		if ip.stack.Len() < 1 {
 			return ip.runtimeverr("failed to run step. log operator failed. stack is empty.\n")
@@ -185,13 +359,12 @@ func (ip *Interpreter) Step() (bool, error) {
 			return ip.runtimeverr("failed to run step. log operator failed. failed to get value: %v\n", err)
 		}
 
-		n1, ok := v1.Int()
-		if !ok {
-			return ip.runtimeverr("failed to run step. log operator failed. failed to get int value.")
+		if !v1.IsNumber() {
+			return ip.runtimeverr("failed to run step. log operator failed. value is %s, not a number.\n", v1.Kind())
 		}
-		ip.runtimev("popped %d\n", n1)
+		ip.runtimev("popped %s\n", v1.Format())
 
-		fmt.Printf("%d\n", n1)
+		fmt.Printf("%s\n", v1.Format())
 	} else if token.Equals("puts", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 1 {
 			return ip.runtimeverr("failed to run step. puts operator failed. stack is empty.\n")
@@ -215,42 +388,60 @@ func (ip *Interpreter) Step() (bool, error) {
 			return ip.runtimeverr("failed to run step. int operator failed. stack is empty.\n")
 		}
 
-		ip.runtimev("parsing string to int.\n")
+		ip.runtimev("converting value to int.\n")
 		v1, err := ip.pop()
 		if err != nil {
 			return ip.runtimeverr("failed to run step. int operator failed. failed to get value: %v\n", err)
 		}
+		ip.runtimev("popped %s\n", v1.Format())
+
+		var parsed int
+		var ok bool = true
+		if s1, isString := v1.String(); isString {
+			parsed, err = strconv.Atoi(s1)
+			ok = err == nil
+		} else if n1, isInt := v1.Int(); isInt {
+			parsed = n1
+		} else if f1, isFloat := v1.Float(); isFloat {
+			parsed = int(f1)
+		} else if b1, isBool := v1.Bool(); isBool {
+			if b1 {
+				parsed = 1
+			}
+		} else {
+			return ip.runtimeverr("failed to run step. int operator failed. cannot convert %s to int.\n", v1.Kind())
+		}
 
-		s1, ok := v1.String()
-		if !ok {
-			return ip.runtimeverr("failed to run step. int operator failed. value is not a string.\n")
+		status, err := ip.pushConversion(StackInt(parsed), ok)
+		if !status || err != nil {
+			return status, err
+		}
+	} else if token.Equals("float", types.TokenTypeKeyword) {
+		if ip.stack.Len() < 1 {
+			return ip.runtimeverr("failed to run step. float operator failed. stack is empty.\n")
 		}
-		ip.runtimev("popped \"%s\"\n", s1)
 
-		parsed, err := strconv.Atoi(s1)
+		ip.runtimev("converting value to float.\n")
+		v1, err := ip.pop()
 		if err != nil {
-			ip.runtimev("parse failed, pushing 0\n")
-			err := ip.ipush(0)
-			if err != nil {
-				return false, fmt.Errorf("failed to run step. int operator failed. failure pushing value: %v", err)
-			}
-			ip.runtimev("pushed %d\n", 0)
-			err = ip.ipush(0)
-			if err != nil {
-				return false, fmt.Errorf("failed to run step. int operator failed. failure pushing value: %v", err)
-			}
-			ip.runtimev("pushed %d (false)\n", 0)
+			return ip.runtimeverr("failed to run step. float operator failed. failed to get value: %v\n", err)
+		}
+		ip.runtimev("popped %s\n", v1.Format())
+
+		var parsed float64
+		var ok bool = true
+		if s1, isString := v1.String(); isString {
+			parsed, err = strconv.ParseFloat(s1, 64)
+			ok = err == nil
+		} else if f1, isNumber := v1.Number(); isNumber {
+			parsed = f1
 		} else {
-			err := ip.ipush(parsed)
-			if err != nil {
-				return false, fmt.Errorf("failed to run step. int operator failed. failure pushing value: %v", err)
-			}
-			ip.runtimev("pushed %d\n", parsed)
-			err = ip.ipush(1)
-			if err != nil {
-				return false, fmt.Errorf("failed to run step. int operator failed. failure pushing value: %v", err)
-			}
-			ip.runtimev("pushed %d (true)\n", 1)
+			return ip.runtimeverr("failed to run step. float operator failed. cannot convert %s to float.\n", v1.Kind())
+		}
+
+		status, err := ip.pushConversion(StackFloat(parsed), ok)
+		if !status || err != nil {
+			return status, err
 		}
 	} else if token.Equals("string", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 1 {
@@ -278,6 +469,24 @@ func (ip *Interpreter) Step() (bool, error) {
 				return false, fmt.Errorf("failed to run step. string operator failed. failure pushing value: %v", err)
 			}
 			ip.runtimev("pushed \"%s\"\n", result)
+		} else if b1, ok := v1.Bool(); ok {
+			result := tools.ToolToStringBool(b1)
+			ip.runtimev("popped %s\n", result)
+			err := ip.spush(result)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. string operator failed. failure pushing value: %v", err)
+			}
+			ip.runtimev("pushed \"%s\"\n", result)
+		} else if f1, ok := v1.Float(); ok {
+			result := tools.ToolToStringFloat(f1)
+			ip.runtimev("popped %s\n", result)
+			err := ip.spush(result)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. string operator failed. failure pushing value: %v", err)
+			}
+			ip.runtimev("pushed \"%s\"\n", result)
+		} else {
+			return ip.runtimeverr("failed to run step. string operator failed. cannot convert %s. use tostring instead.\n", v1.Kind())
 		}
 	} else if token.Equals("store", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 2 {
@@ -300,7 +509,10 @@ func (ip *Interpreter) Step() (bool, error) {
 			return ip.runtimeverr("failed to run step. store operator failed. failed to get value: %v\n", err)
 		}
 
-		ip.store(name, vValue)
+		err = ip.store(name, vValue)
+		if err != nil {
+			return ip.runtimeverr("failed to run step. store operator failed. failed to store memory: %v\n", err)
+		}
 	} else if token.Equals("load", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 1 {
 			return ip.runtimeverr("failed to run step. load operator failed. stack is empty.\n")
@@ -317,19 +529,29 @@ func (ip *Interpreter) Step() (bool, error) {
 			return ip.runtimeverr("failed to run step. load operator failed. failed to get name value.\n")
 		}
 
-		value, err := ip.load(name)
+		value, found, err := ip.load(name)
 		if err != nil {
 			return ip.runtimeverr("failed to run step. load operator failed. failed to load memory: %v\n", err)
 		}
 
-		ip.push(value)
+		if !found {
+			err = ip.bpush(false)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. load operator failed. failure pushing value: %v", err)
+			}
+			ip.runtimev("pushed false\n")
+		} else {
+			err = ip.push(value)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. load operator failed. failure pushing value: %v", err)
+			}
+			ip.runtimev("pushed %s\n", value.Format())
 
-		left, okLeft := value.String()
-		right, okRight := value.Int()
-		if okLeft {
-			ip.runtimev("pushed \"%s\"\n", left)
-		} else if okRight {
-			ip.runtimev("pushed %d\n", right)
+			err = ip.bpush(true)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. load operator failed. failure pushing value: %v", err)
+			}
+			ip.runtimev("pushed true\n")
 		}
 	} else if token.Equals("+", types.TokenTypeSymbol) {
 		if ip.stack.Len() < 2 {
@@ -337,1267 +559,1071 @@ func (ip *Interpreter) Step() (bool, error) {
 		}
 
 		ip.runtimev("adding two numbers, concating two strings, or concating string + number.\n")
-		v1, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. + operator failed. failed to get first value: %v\n", err)
-		}
-
-		v2, err := ip.pop()
+		v2, err := ip.peekOffset(1)
 		if err != nil {
 			return ip.runtimeverr("failed to run step. + operator failed. failed to get second value: %v\n", err)
 		}
 
-		if s2, ok := v2.String(); ok {
-			if s1, ok := v1.String(); ok {
-				ip.runtimev("popped \"%s\"\n", s1)
-				ip.runtimev("popped \"%s\"\n", s2)
-
-				result := s2 + s1
-
-				err := ip.spush(result)
-				if err != nil {
-					return false, fmt.Errorf("failed to run step. + operator failed. failure pushing value: %v", err)
-				}
-				ip.runtimev("pushed \"%s\"\n", result)
-			} else if n1, ok := v1.Int(); ok {
-				ip.runtimev("popped %d\n", n1)
-				ip.runtimev("popped \"%s\"\n", s2)
+		if !v2.IsString() {
+			status, err := ip.arithmetic("+", "add", func(a, b int) int { return a + b }, func(a, b float64) float64 { return a + b })
+			if !status || err != nil {
+				return status, err
+			}
+		} else {
+			v1, err := ip.pop()
+			if err != nil {
+				return ip.runtimeverr("failed to run step. + operator failed. failed to get first value: %v\n", err)
+			}
+			ip.runtimev("popped %s\n", v1.Format())
 
-				result := fmt.Sprintf("%s%d", s2, n1)
+			v2, err := ip.pop()
+			if err != nil {
+				return ip.runtimeverr("failed to run step. + operator failed. failed to get second value: %v\n", err)
+			}
+			ip.runtimev("popped %s\n", v2.Format())
 
-				err := ip.spush(result)
-				if err != nil {
-					return false, fmt.Errorf("failed to run step. + operator failed. failure pushing value: %v", err)
-				}
-				ip.runtimev("pushed \"%s\"\n", result)
+			s2, _ := v2.String()
+			var result string
+			if s1, ok := v1.String(); ok {
+				result = s2 + s1
+			} else if v1.IsNumber() || v1.IsBool() {
+				result = s2 + v1.Format()
 			} else {
-				return ip.runtimeverr("failed to run step. + operator failed. failed to get first value type.\n")
+				return ip.runtimeverr("failed to run step. + operator failed. cannot add %s to string.\n", v1.Kind())
 			}
-		} else if n2, ok := v2.Int(); ok {
-			if n1, ok := v1.Int(); ok {
-				ip.runtimev("popped %d\n", n1)
-				ip.runtimev("popped %d\n", n2)
-
-				result := n2 + n1
 
-				err := ip.ipush(result)
-				if err != nil {
-					return false, fmt.Errorf("failed to run step. + operator failed. failure pushing value: %v", err)
-				}
-				ip.runtimev("pushed %d\n", result)
-			} else if v1.IsString() {
-				return ip.runtimeverr("failed to run step. + operator failed. second value is int, thus first value must be int.\n")
-			} else {
-				return ip.runtimeverr("failed to run step. + operator failed. failed to get first value type.\n")
+			err = ip.spush(result)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. + operator failed. failure pushing value: %v", err)
 			}
-		} else {
-			return ip.runtimeverr("failed to run step. + operator failed. failed to get second value type.\n")
+			ip.runtimev("pushed \"%s\"\n", result)
 		}
 	} else if token.Equals("-", types.TokenTypeSymbol) {
-		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. - operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
-		}
-
 		ip.runtimev("subtracting two numbers.\n")
-		v1, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. - operator failed. failed to get first value: %v\n", err)
+		status, err := ip.arithmetic("-", "subtract", func(a, b int) int { return a - b }, func(a, b float64) float64 { return a - b })
+		if !status || err != nil {
+			return status, err
 		}
-
-		v2, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. - operator failed. failed to get second value: %v\n", err)
+	} else if token.Equals("*", types.TokenTypeSymbol) {
+		ip.runtimev("multiplying two numbers.\n")
+		status, err := ip.arithmetic("*", "multiply", func(a, b int) int { return a * b }, func(a, b float64) float64 { return a * b })
+		if !status || err != nil {
+			return status, err
 		}
-
-		if v2.IsString() {
-			return ip.runtimeverr("failed to run step. - operator failed. cannot subtract from string.\n")
+	} else if token.Equals("/", types.TokenTypeSymbol) {
+		ip.runtimev("dividing two numbers.\n")
+		status, err := ip.arithmetic("/", "divide", divInt, divFloat)
+		if !status || err != nil {
+			return status, err
 		}
-
-		if v1.IsString() {
-			return ip.runtimeverr("failed to run step. - operator failed. cannot subtract string.\n")
+	} else if token.Equals("%", types.TokenTypeSymbol) {
+		ip.runtimev("modulo two numbers.\n")
+		status, err := ip.arithmetic("%", "modulo", modInt, modFloat)
+		if !status || err != nil {
+			return status, err
 		}
-
-		n1, ok := v1.Int()
-		if !ok {
-			return ip.runtimeverr("failed to run step. - operator failed. failed to get first value type.\n")
+	} else if token.Equals("++", types.TokenTypeSymbol) {
+		ip.runtimev("incrementing number.\n")
+		status, err := ip.increment("++", 1)
+		if !status || err != nil {
+			return status, err
+		}
+	} else if token.Equals("--", types.TokenTypeSymbol) {
+		ip.runtimev("decrementing number.\n")
+		status, err := ip.increment("--", -1)
+		if !status || err != nil {
+			return status, err
+		}
+	} else if token.Equals("dup", types.TokenTypeKeyword) {
+		if ip.stack.Len() < 1 {
+			return ip.runtimeverr("failed to run step. dup operator failed. stack is empty.\n")
 		}
-		ip.runtimev("popped %d\n", n1)
 
-		n2, ok := v2.Int()
-		if !ok {
-			return ip.runtimeverr("failed to run step. - operator failed. failed to get second value type.\n")
+		ip.runtimev("duplicate top stack value.\n")
+		v1, err := ip.pop()
+		if err != nil {
+			return ip.runtimeverr("failed to run step. dup operator failed. failed to get value: %v\n", err)
 		}
-		ip.runtimev("popped %d\n", n2)
+		ip.runtimev("popped %s\n", v1.Format())
 
-		result := n2 - n1
+		for range 2 {
+			err = ip.push(v1)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. dup operator failed. failure pushing value: %v", err)
+			}
+			ip.runtimev("pushed %s\n", v1.Format())
+		}
+	} else if token.Equals("drop", types.TokenTypeKeyword) {
+		if ip.stack.Len() < 1 {
+			return ip.runtimeverr("failed to run step. drop operator failed. stack is empty.\n")
+		}
 
-		err = ip.ipush(result)
+		ip.runtimev("dropping top stack value.\n")
+		v1, err := ip.pop()
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. - operator failed. failure pushing value: %v", err)
+			return ip.runtimeverr("failed to run step. drop operator failed. failed to pop value: %v\n", err)
 		}
-		ip.runtimev("pushed %d\n", result)
-	} else if token.Equals("*", types.TokenTypeSymbol) {
+		ip.runtimev("dropped %s\n", v1.Format())
+	} else if token.Equals("swap", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. * operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
+			return ip.runtimeverr("failed to run step. swap operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
 		}
 
-		ip.runtimev("multiplying two numbers.\n")
+		ip.runtimev("swapping top two stack values.\n")
 		v1, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. * operator failed. failed to get first value: %v\n", err)
+			return ip.runtimeverr("failed to run step. swap operator failed. failed to get first value: %v\n", err)
 		}
+		ip.runtimev("popped %s\n", v1.Format())
 
 		v2, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. * operator failed. failed to get second value: %v\n", err)
-		}
-
-		if v2.IsString() {
-			return ip.runtimeverr("failed to run step. * operator failed. cannot multiply string.\n")
+			return ip.runtimeverr("failed to run step. swap operator failed. failed to get second value: %v\n", err)
 		}
+		ip.runtimev("popped %s\n", v2.Format())
 
-		if v1.IsString() {
-			return ip.runtimeverr("failed to run step. * operator failed. cannot multiply string.\n")
+		for _, v := range []StackValue{v1, v2} {
+			err = ip.push(v)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. swap operator failed. failure pushing value: %v", err)
+			}
+			ip.runtimev("pushed %s\n", v.Format())
 		}
-
-		n1, ok := v1.Int()
-		if !ok {
-			return ip.runtimeverr("failed to run step. * operator failed. failed to get first value type.\n")
+	} else if token.Equals("over", types.TokenTypeKeyword) {
+		if ip.stack.Len() < 2 {
+			return ip.runtimeverr("failed to run step. over operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
 		}
-		ip.runtimev("popped %d\n", n1)
 
-		n2, ok := v2.Int()
-		if !ok {
-			return ip.runtimeverr("failed to run step. * operator failed. failed to get second value type.\n")
+		ip.runtimev("pushing 2nd top-most value onto stack.\n")
+		v2, err := ip.peekOffset(1)
+		if err != nil {
+			return ip.runtimeverr("failed to run step. over operator failed. failed to get second value: %v\n", err)
 		}
-		ip.runtimev("popped %d\n", n2)
+		ip.runtimev("peeked(1) %s\n", v2.Format())
 
-		result := n1 * n2
-
-		err = ip.ipush(result)
+		err = ip.push(v2)
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. * operator failed. failure pushing value: %v", err)
+			return false, fmt.Errorf("failed to run step. over operator failed. failure pushing value: %v", err)
 		}
-		ip.runtimev("pushed %d\n", result)
-	} else if token.Equals("/", types.TokenTypeSymbol) {
+		ip.runtimev("pushed %s\n", v2.Format())
+	} else if token.Equals("2dup", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. / operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
+			return ip.runtimeverr("failed to run step. 2dup operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
 		}
 
-		ip.runtimev("dividing two numbers.\n")
-		v1, err := ip.pop()
+		ip.runtimev("two-duping top stack values.\n")
+		v1, err := ip.peekOffset(1)
 		if err != nil {
-			return ip.runtimeverr("failed to run step. / operator failed. failed to get first value: %v\n", err)
+			return ip.runtimeverr("failed to run step. 2dup operator failed. failed to get first value: %v\n", err)
 		}
+		ip.runtimev("peeked(1) %s\n", v1.Format())
 
-		v2, err := ip.pop()
+		v2, err := ip.peekOffset(0)
 		if err != nil {
-			return ip.runtimeverr("failed to run step. / operator failed. failed to get second value: %v\n", err)
-		}
-
-		if v2.IsString() {
-			return ip.runtimeverr("failed to run step. / operator failed. cannot divide string.\n")
+			return ip.runtimeverr("failed to run step. 2dup operator failed. failed to get second value: %v\n", err)
 		}
+		ip.runtimev("peeked(0) %s\n", v2.Format())
 
-		if v1.IsString() {
-			return ip.runtimeverr("failed to run step. / operator failed. cannot divide string.\n")
-		}
-
-		n1, ok := v1.Int()
-		if !ok {
-			return ip.runtimeverr("failed to run step. / operator failed. failed to get first value type.\n")
+		for _, v := range []StackValue{v1, v2} {
+			err = ip.push(v)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. 2dup operator failed. failure pushing value: %v", err)
+			}
+			ip.runtimev("pushed %s\n", v.Format())
 		}
-		ip.runtimev("popped %d\n", n1)
-
-		n2, ok := v2.Int()
-		if !ok {
-			return ip.runtimeverr("failed to run step. / operator failed. failed to get second value type.\n")
+	} else if token.Equals("2swap", types.TokenTypeKeyword) {
+		if ip.stack.Len() < 4 {
+			return ip.runtimeverr("failed to run step. 2swap operator failed. stack size is %d. 4 is reqired.\n", ip.stack.Len())
 		}
-		ip.runtimev("popped %d\n", n2)
 
-		var result int
-		if n1 != 0 {
-			result = n2 / n1
+		ip.runtimev("two-swapping top stack values.\n")
+		v1, err := ip.pop()
+		if err != nil {
+			return ip.runtimeverr("failed to run step. 2swap operator failed. failed to get first value: %v\n", err)
 		}
 
-		err = ip.ipush(result)
+		v2, err := ip.pop()
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. / operator failed. failure pushing value: %v", err)
-		}
-		ip.runtimev("pushed %d\n", result)
-	} else if token.Equals("%", types.TokenTypeSymbol) {
-		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. % operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
+			return ip.runtimeverr("failed to run step. 2swap operator failed. failed to get second value: %v\n", err)
 		}
 
-		ip.runtimev("modulo two numbers.\n")
-		v1, err := ip.pop()
+		v3, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. % operator failed. failed to get first value: %v\n", err)
+			return ip.runtimeverr("failed to run step. 2swap operator failed. failed to get third value: %v\n", err)
 		}
 
-		v2, err := ip.pop()
+		v4, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. % operator failed. failed to get second value: %v\n", err)
+			return ip.runtimeverr("failed to run step. 2swap operator failed. failed to get fourth value: %v\n", err)
 		}
 
-		if v2.IsString() {
-			return ip.runtimeverr("failed to run step. % operator failed. cannot modulo string.\n")
+		for _, v := range []StackValue{v1, v2, v3, v4} {
+			ip.runtimev("popped %s\n", v.Format())
 		}
 
-		if v1.IsString() {
-			return ip.runtimeverr("failed to run step. % operator failed. cannot modulo string.\n")
+		for _, v := range []StackValue{v2, v1, v4, v3} {
+			err = ip.push(v)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. 2swap operator failed. failure pushing value: %v", err)
+			}
+			ip.runtimev("pushed %s\n", v.Format())
 		}
-
-		n1, ok := v1.Int()
-		if !ok {
-			return ip.runtimeverr("failed to run step. % operator failed. failed to get first value type.\n")
+	} else if token.Equals("if", types.TokenTypeKeyword) {
+		if ip.stack.Len() < 1 {
+			return ip.runtimeverr("failed to run step. if operator failed. stack is empty.\n")
 		}
-		ip.runtimev("popped %d\n", n1)
 
-		n2, ok := v2.Int()
-		if !ok {
-			return ip.runtimeverr("failed to run step. % operator failed. failed to get second value type.\n")
+		ip.runtimev("validating if-condition.\n")
+		v1, err := ip.pop()
+		if err != nil {
+			return ip.runtimeverr("failed to run step. if operator failed. failed to get condition value: %v\n", err)
 		}
-		ip.runtimev("popped %d\n", n2)
 
-		var result int
-		if n1 != 0 {
-			result = n2 % n1
-		}
+		ip.runtimev("popped %s\n", v1.Format())
+		truthy := v1.Truthy()
 
-		err = ip.ipush(result)
-		if err != nil {
-			return false, fmt.Errorf("failed to run step. %% operator failed. failure pushing value: %v", err)
+		if !truthy {
+			ip.ip = int(inst.Next)
+			return true, nil
 		}
-		ip.runtimev("pushed %d\n", result)
-	} else if token.Equals("++", types.TokenTypeSymbol) {
+	} else if token.Equals("unless", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 1 {
-			return ip.runtimeverr("failed to run step. ++ operator failed. stack is empty.\n")
+			return ip.runtimeverr("failed to run step. unless operator failed. stack is empty.\n")
 		}
 
-		ip.runtimev("increment top stack value.\n")
+		ip.runtimev("validating unless-condition.\n")
 		v1, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. ++ operator failed. failed to get value: %v\n", err)
-		}
-
-		n1, ok := v1.Int()
-		if !ok {
-			return ip.runtimeverr("failed to run step. ++ operator failed. failed tog et value type.\n")
+			return ip.runtimeverr("failed to run step. unless operator failed. failed to get condition value: %v\n", err)
 		}
-		ip.runtimev("popped %d\n", n1)
 
-		result := n1 + 1
+		ip.runtimev("popped %s\n", v1.Format())
+		truthy := v1.Truthy()
 
-		err = ip.ipush(result)
-		if err != nil {
-			return false, fmt.Errorf("failed to run step. ++ operator failed. failure pushing value: %v", err)
+		if truthy {
+			ip.ip = int(inst.Next)
+			return true, nil
 		}
-		ip.runtimev("pushed %d\n", result)
-	} else if token.Equals("--", types.TokenTypeSymbol) {
+	} else if token.Equals("else", types.TokenTypeKeyword) {
+		if inst.Next != -1 {
+			ip.ip = int(inst.Next)
+			return true, nil
+		}
+	} else if token.Equals("do", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 1 {
-			return ip.runtimeverr("failed to run step. -- operator failed. stack is empty.\n")
+			return ip.runtimeverr("failed to run step. do operator failed. stack is empty.\n")
 		}
 
-		ip.runtimev("decrement top stack value.\n")
+		ip.runtimev("validating do-condition.\n")
 		v1, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. -- operator failed. failed to get value: %v\n", err)
+			return ip.runtimeverr("failed to run step. do operator failed. failed to get condition value: %v\n", err)
 		}
 
-		n1, ok := v1.Int()
-		if !ok {
-			return ip.runtimeverr("failed to run step. -- operator failed. failed tog et value type.\n")
-		}
-		ip.runtimev("popped %d\n", n1)
+		ip.runtimev("popped %s\n", v1.Format())
+		truthy := v1.Truthy()
 
-		result := n1 - 1
+		if inst.Mode == DoModeUntil {
+			truthy = !truthy
+		}
 
-		err = ip.ipush(result)
-		if err != nil {
-			return false, fmt.Errorf("failed to run step. -- operator failed. failure pushing value: %v", err)
+		if !truthy {
+			ip.popScope()
+			ip.ip = int(inst.Next)
+			return true, nil
 		}
-		ip.runtimev("pushed %d\n", result)
-	} else if token.Equals("dup", types.TokenTypeKeyword) {
-		if ip.stack.Len() < 1 {
-			return ip.runtimeverr("failed to run step. dup operator failed. stack is empty.\n")
+	} else if token.Equals("end", types.TokenTypeKeyword) {
+		if inst.Mode == EndModeReturn {
+			return ip.ret()
 		}
 
-		ip.runtimev("duplicate top stack value.\n")
-		v1, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. dup operator failed. failed to get value: %v\n", err)
+		if inst.Mode == EndModeEach {
+			return ip.next(int(inst.Next))
 		}
 
-		if s1, ok := v1.String(); ok {
-			ip.runtimev("popped \"%s\"\n", s1)
-			err := ip.spush(s1)
-			if err != nil {
-				return false, fmt.Errorf("failed to run step. dup operator failed. failure pushing value: %v", err)
-			}
-			ip.runtimev("pushed \"%s\"\n", s1)
-			err = ip.spush(s1)
-			if err != nil {
-				return false, fmt.Errorf("failed to run step. dup operator failed. failure pushing value: %v", err)
-			}
-			ip.runtimev("pushed \"%s\"\n", s1)
-		} else if n1, ok := v1.Int(); ok {
-			ip.runtimev("popped %d\n", n1)
-			err := ip.ipush(n1)
-			if err != nil {
-				return false, fmt.Errorf("failed to run step. dup operator failed. failure pushing value: %v", err)
-			}
-			ip.runtimev("pushed %d\n", n1)
-			err = ip.ipush(n1)
-			if err != nil {
-				return false, fmt.Errorf("failed to run step. dup operator failed. failure pushing value: %v", err)
-			}
-			ip.runtimev("pushed %d\n", n1)
-		} else if p1, ok := v1.Path(); ok {
-			ip.runtimev("popped %s\n", p1)
-			err := ip.ppush(p1)
-			if err != nil {
-				return false, fmt.Errorf("failed to run step. dup operator failed. failure pushing value: %v", err)
-			}
-			ip.runtimev("pushed %s\n", p1)
-			err = ip.ppush(p1)
-			if err != nil {
-				return false, fmt.Errorf("failed to run step. dup operator failed. failure pushing value: %v", err)
-			}
-			ip.runtimev("pushed %s\n", p1)
+		if inst.Next != -1 {
+			ip.popScope()
+			ip.ip = int(inst.Next)
+			return true, nil
 		}
-	} else if token.Equals("drop", types.TokenTypeKeyword) {
+	} else if token.Equals("proc", types.TokenTypeKeyword) {
+		ip.runtimev("skipping proc body.\n")
+		ip.ip = int(inst.Next)
+		return true, nil
+	} else if token.Equals("return", types.TokenTypeKeyword) {
+		return ip.ret()
+	} else if inst.IsCall() {
+		if ip.calls.Len() >= maxCallDepth {
+			return ip.runtimeverr("failed to run step. call to proc '%s' failed. call depth limit %d reached.\n", token.Value, maxCallDepth)
+		}
+
+		ip.runtimev("calling proc '%s'.\n", token.Value)
+		ip.calls.Push(Frame{
+			Proc: token.Value,
+			Site: token.Pos,
+			Return: ip.ip + 1,
+			Scopes: []Scope{nil},
+			Iters: ip.iters.Len(),
+		})
+
+		ip.ip = int(inst.Next)
+		return true, nil
+	} else if token.Equals("while", types.TokenTypeKeyword) {
+		ip.runtimev("while (enter loop scope).\n")
+		ip.pushScope()
+	} else if token.Equals("until", types.TokenTypeKeyword) {
+		ip.runtimev("until (enter loop scope).\n")
+		ip.pushScope()
+	} else if token.Equals("each", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 1 {
-			return ip.runtimeverr("failed to run step. drop operator failed. stack is empty.\n")
+			return ip.runtimeverr("failed to run step. each operator failed. stack is empty.\n")
 		}
 
-		ip.runtimev("dropping top stack value.\n")
+		ip.runtimev("each (enter loop).\n")
 		v1, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. drop operator failed. failed to pop value: %v\n", err)
+			return ip.runtimeverr("failed to run step. each operator failed. failed to get value: %v\n", err)
 		}
+		ip.runtimev("popped %s\n", v1.Format())
 
-		if s1, ok := v1.String(); ok {
-			ip.runtimev("dropped \"%s\"\n", s1)
-		} else if n1, ok := v1.Int(); ok {
-			ip.runtimev("dropped %d\n", n1)
-		} else if p1, ok := v1.Path(); ok {
-			ip.runtimev("dropped %s\n", p1)
-		}
-	} else if token.Equals("swap", types.TokenTypeKeyword) {
-		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. swap operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
+		var iter iterator
+		if l1, ok := v1.List(); ok {
+			iter.items = l1
+		} else if m1, ok := v1.Map(); ok {
+			iter.keys = sortedKeys(m1)
+			iter.items = make([]StackValue, len(iter.keys))
+			for idx, key := range iter.keys {
+				iter.items[idx] = m1[key]
+			}
+		} else {
+			return ip.runtimeverr("failed to run step. each operator failed. value is %s, not a list or map.\n", v1.Kind())
 		}
 
-		ip.runtimev("swapping top two stack values.\n")
-		v1, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. swap operator failed. failed to get first value: %v\n", err)
+		if len(iter.items) == 0 {
+			ip.ip = int(inst.Next)
+			return true, nil
 		}
 
-		v2, err := ip.pop()
+		ip.iters.Push(iter)
+		ip.pushScope()
+
+		err = ip.pushItem(&iter)
 		if err != nil {
-			return ip.runtimeverr("failed to run step. swap operator failed. failed to get second value: %v\n", err)
+			return false, fmt.Errorf("failed to run step. each operator failed. failure pushing value: %v", err)
 		}
-
-		if s1, ok := v1.String(); ok {
-			ip.runtimev("popped \"%s\"\n", s1)
-		} else if n1, ok := v1.Int(); ok {
-			ip.runtimev("popped %d\n", n1)
-		} else if p1, ok := v1.Path(); ok {
-			ip.runtimev("dropped %s\n", p1)
+	} else if token.Equals(scopeEnter, types.TokenTypeKeyword) {
+		ip.runtimev("enter macro scope.\n")
+		ip.pushScope()
+	} else if token.Equals(macroBind, types.TokenTypeKeyword) {
+		status, err := ip.bind(ip.program[ip.ip].Expansion)
+		if !status || err != nil {
+			return status, err
 		}
-
-		if s2, ok := v2.String(); ok {
-			ip.runtimev("popped \"%s\"\n", s2)
-		} else if n2, ok := v2.Int(); ok {
-			ip.runtimev("popped %d\n", n2)
-		} else if p2, ok := v2.Path(); ok {
-			ip.runtimev("dropped %s\n", p2)
+	} else if token.Equals(scopeLeave, types.TokenTypeKeyword) {
+		ip.runtimev("leave macro scope.\n")
+		ip.popScope()
+	} else if token.Equals("=", types.TokenTypeSymbol) {
+		ip.runtimev("equality-check top stack values.\n")
+		status, err := ip.equality("=", true)
+		if !status || err != nil {
+			return status, err
 		}
-
-		err = ip.push(v1)
-		if err != nil {
-			return false, fmt.Errorf("failed to run step. swap operator failed. failure pushing value: %v", err)
+	} else if token.Equals("!=", types.TokenTypeSymbol) {
+		ip.runtimev("inequality-check top stack values.\n")
+		status, err := ip.equality("!=", false)
+		if !status || err != nil {
+			return status, err
 		}
-		if s1, ok := v1.String(); ok {
-			ip.runtimev("pushed \"%s\"\n", s1)
-		} else if n1, ok := v1.Int(); ok {
-			ip.runtimev("pushed %d\n", n1)
-		} else if p1, ok := v1.Path(); ok {
-			ip.runtimev("pushed %s\n", p1)
+	} else if token.Equals("<", types.TokenTypeSymbol) {
+		ip.runtimev("less-check top stack values.\n")
+		status, err := ip.comparison("<", func(order int) bool { return order < 0 })
+		if !status || err != nil {
+			return status, err
 		}
-
-		err = ip.push(v2)
-		if err != nil {
-			return false, fmt.Errorf("failed to run step. swap operator failed. failure pushing value: %v", err)
+	} else if token.Equals(">", types.TokenTypeSymbol) {
+		ip.runtimev("greater-check top stack values.\n")
+		status, err := ip.comparison(">", func(order int) bool { return order > 0 })
+		if !status || err != nil {
+			return status, err
+		}
+	} else if token.Equals("<=", types.TokenTypeSymbol) {
+		ip.runtimev("less-equal-check top stack values.\n")
+		status, err := ip.comparison("<=", func(order int) bool { return order <= 0 })
+		if !status || err != nil {
+			return status, err
 		}
-		if s2, ok := v2.String(); ok {
-			ip.runtimev("pushed \"%s\"\n", s2)
-		} else if n2, ok := v2.Int(); ok {
-			ip.runtimev("pushed %d\n", n2)
-		} else if p2, ok := v2.Path(); ok {
-			ip.runtimev("pushed %s\n", p2)
+	} else if token.Equals(">=", types.TokenTypeSymbol) {
+		ip.runtimev("greater-equal-check top stack values.\n")
+		status, err := ip.comparison(">=", func(order int) bool { return order >= 0 })
+		if !status || err != nil {
+			return status, err
 		}
-	} else if token.Equals("over", types.TokenTypeKeyword) {
-		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. over operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
+	} else if token.Equals("!", types.TokenTypeSymbol) {
+		if ip.stack.Len() < 1 {
+			return ip.runtimeverr("failed to run step. ! operator failed. stack is empty.\n")
 		}
 
-		ip.runtimev("pushing 2nd top-most value onto stack.\n")
-		v2, err := ip.peekOffset(1)
+		ip.runtimev("logicalnot top stack value.\n")
+		v1, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. over operator failed. failed to get second value: %v\n", err)
+			return ip.runtimeverr("failed to run step. ! operator failed. failed to get value: %v\n", err)
 		}
 
-		if s2, ok := v2.String(); ok {
-			ip.runtimev("peeked(1) \"%s\"\n", s2)
-			err := ip.spush(s2)
-			if err != nil {
-				return false, fmt.Errorf("failed to run step. over operator failed. failure pushing value: %v", err)
-			}
-			ip.runtimev("pushed \"%s\"\n", s2)
-		} else if n2, ok := v2.Int(); ok {
-			ip.runtimev("peeked(1) %d\n", n2)
-			err := ip.ipush(n2)
-			if err != nil {
-				return false, fmt.Errorf("failed to run step. over operator failed. failure pushing value: %v", err)
-			}
-			ip.runtimev("pushed %d\n", n2)
-		} else if p2, ok := v2.Path(); ok {
-			ip.runtimev("peeked(1) %d\n", p2)
-			err := ip.ppush(p2)
-			if err != nil {
-				return false, fmt.Errorf("failed to run step. over operator failed. failure pushing value: %v", err)
-			}
-			ip.runtimev("pushed %s\n", p2)
-		}
-	} else if token.Equals("2dup", types.TokenTypeKeyword) {
-		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. 2dup operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
-		}
+		ip.runtimev("popped %s\n", v1.Format())
+		truthy := v1.Truthy()
 
-		ip.runtimev("two-duping top stack values.\n")
-		v1, err := ip.peekOffset(1)
+		result := !truthy
+
+		err = ip.bpush(result)
 		if err != nil {
-			return ip.runtimeverr("failed to run step. 2dup operator failed. failed to get first value: %v", err)
+			return false, fmt.Errorf("failed to run step. ! operator failed. failure pushing value: %v", err)
+		}
+		ip.runtimev("pushed %t\n", result)
+	} else if token.Equals("~", types.TokenTypeSymbol) {
+		if ip.stack.Len() < 1 {
+			return ip.runtimeverr("failed to run step. ~ operator failed. stack is empty.\n")
 		}
 
-		v2, err := ip.peekOffset(0)
+		ip.runtimev("bitwisenot top stack value.\n")
+		v1, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. 2dup operator failed. failed to get second value: %v", err)
+			return ip.runtimeverr("failed to run step. ~ operator failed. failed to get value: %v\n", err)
 		}
 
-		if s1, ok := v1.String(); ok {
-			ip.runtimev("peeked(1) \"%s\"\n", s1)
-		} else if n1, ok := v1.Int(); ok {
-			ip.runtimev("peeked(1) %d\n", n1)
-		} else if p1, ok := v1.Path(); ok {
-			ip.runtimev("peeked(1) %s\n", p1)
+		if v1.IsString() {
+			return ip.runtimeverr("failed to run step. ~ operator failed. cannot bitwise not string.\n")
 		}
 
-		if s2, ok := v2.String(); ok {
-			ip.runtimev("peeked(0) \"%s\"\n", s2)
-		} else if n2, ok := v2.Int(); ok {
-			ip.runtimev("peeked(0) %d\n", n2)
-		} else if p2, ok := v2.Path(); ok {
-			ip.runtimev("peeked(0) %s\n", p2)
+		n1, ok := v1.Int()
+		if !ok {
+			return ip.runtimeverr("failed to run step. ~ operator failed. failed to get value type.\n")
 		}
+		ip.runtimev("popped %d\n", n1)
 
-		err = ip.push(v1)
-		if err != nil {
-			return false, fmt.Errorf("failed to run step. 2dup operator failed. failure pushing value: %v", err)
-		}
-		if s1, ok := v1.String(); ok {
-			ip.runtimev("pushed \"%s\"\n", s1)
-		} else if n1, ok := v1.Int(); ok {
-			ip.runtimev("pushed %d\n", n1)
-		} else if p1, ok := v1.Path(); ok {
-			ip.runtimev("pushed %s\n", p1)
-		}
+		result := ^n1
 
-		err = ip.push(v2)
+		err = ip.ipush(result)
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. 2dup operator failed. failure pushing value: %v", err)
-		}
-		if s2, ok := v2.String(); ok {
-			ip.runtimev("pushed \"%s\"\n", s2)
-		} else if n2, ok := v2.Int(); ok {
-			ip.runtimev("pushed %d\n", n2)
-		} else if p2, ok := v2.Path(); ok {
-			ip.runtimev("pushed %s\n", p2)
+			return false, fmt.Errorf("failed to run step. ~ operator failed. failure pushing value: %v", err)
 		}
-	} else if token.Equals("2swap", types.TokenTypeKeyword) {
-		if ip.stack.Len() < 4 {
-			return ip.runtimeverr("failed to run step. 2swap operator failed. stack size is %d. 4 is reqired.\n", ip.stack.Len())
+		ip.runtimev("pushed %d\n", result)
+	} else if token.Equals("&", types.TokenTypeSymbol) {
+		if ip.stack.Len() < 2 {
+			return ip.runtimeverr("failed to run step. & operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
 		}
 
-		ip.runtimev("two-swapping top stack values.\n")
+		ip.runtimev("bitwiseand two numbers.\n")
 		v1, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. 2swap operator failed. failed to get first value: %v\n", err)
+			return ip.runtimeverr("failed to run step. & operator failed. failed to get first value: %v\n", err)
 		}
 
 		v2, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. 2swap operator failed. failed to get second value: %v\n", err)
-		}
-
-		v3, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. 2swap operator failed. failed to get third value: %v\n", err)
-		}
-
-		v4, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. 2swap operator failed. failed to get fourth value: %v\n", err)
+			return ip.runtimeverr("failed to run step. & operator failed. failed to get second value: %v\n", err)
 		}
 
-		if s1, ok := v1.String(); ok {
-			ip.runtimev("popped \"%s\"\n", s1)
-		} else if n1, ok := v1.Int(); ok {
-			ip.runtimev("popped %d\n", n1)
-		} else if p1, ok := v1.Path(); ok {
-			ip.runtimev("popped %s\n", p1)
+		if v2.IsString() {
+			return ip.runtimeverr("failed to run step. & operator failed. cannot bitwise and string.\n")
 		}
 
-		if s2, ok := v2.String(); ok {
-			ip.runtimev("popped \"%s\"\n", s2)
-		} else if n2, ok := v2.Int(); ok {
-			ip.runtimev("popped %d\n", n2)
-		} else if p2, ok := v2.Path(); ok {
-			ip.runtimev("popped %s\n", p2)
+		if v1.IsString() {
+			return ip.runtimeverr("failed to run step. & operator failed. cannot bitwise and string.\n")
 		}
 
-		if s3, ok := v3.String(); ok {
-			ip.runtimev("popped \"%s\"\n", s3)
-		} else if n3, ok := v3.Int(); ok {
-			ip.runtimev("popped %d\n", n3)
-		} else if p3, ok := v3.Path(); ok {
-			ip.runtimev("popped %s\n", p3)
+		n1, ok := v1.Int()
+		if !ok {
+			return ip.runtimeverr("failed to run step. & operator failed. failed to get first value type.\n")
 		}
+		ip.runtimev("popped %d\n", n1)
 
-		if s4, ok := v4.String(); ok {
-			ip.runtimev("popped \"%s\"\n", s4)
-		} else if n4, ok := v4.Int(); ok {
-			ip.runtimev("popped %d\n", n4)
-		} else if p4, ok := v4.Path(); ok {
-			ip.runtimev("popped %s\n", p4)
+		n2, ok := v2.Int()
+		if !ok {
+			return ip.runtimeverr("failed to run step. & operator failed. failed to get second value type.\n")
 		}
+		ip.runtimev("popped %d\n", n2)
 
-		err = ip.push(v2)
-		if err != nil {
-			return false, fmt.Errorf("failed to run step. 2swap operator failed. failure pushing value: %v", err)
-		}
-		if s2, ok := v2.String(); ok {
-			ip.runtimev("pushed \"%s\"\n", s2)
-		} else if n2, ok := v2.Int(); ok {
-			ip.runtimev("pushed %d\n", n2)
-		} else if p2, ok := v2.Path(); ok {
-			ip.runtimev("pushed %s\n", p2)
-		}
+		result := n2 & n1
 
-		err = ip.push(v1)
+		err = ip.ipush(result)
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. 2swap operator failed. failure pushing value: %v", err)
+			return false, fmt.Errorf("failed to run step. & operator failed. failure pushing value: %v", err)
 		}
-		if s1, ok := v1.String(); ok {
-			ip.runtimev("pushed \"%s\"\n", s1)
-		} else if n1, ok := v1.Int(); ok {
-			ip.runtimev("pushed %d\n", n1)
-		} else if p1, ok := v1.Path(); ok {
-			ip.runtimev("pushed %s\n", p1)
+		ip.runtimev("pushed %d\n", result)
+	} else if token.Equals("|", types.TokenTypeSymbol) {
+		if ip.stack.Len() < 2 {
+			return ip.runtimeverr("failed to run step. | operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
 		}
 
-		err = ip.push(v4)
+		ip.runtimev("bitwiseor two numbers.\n")
+		v1, err := ip.pop()
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. 2swap operator failed. failure pushing value: %v", err)
-		}
-		if s4, ok := v4.String(); ok {
-			ip.runtimev("pushed \"%s\"\n", s4)
-		} else if n4, ok := v4.Int(); ok {
-			ip.runtimev("pushed %d\n", n4)
-		} else if p4, ok := v4.Path(); ok {
-			ip.runtimev("pushed %s\n", p4)
+			return ip.runtimeverr("failed to run step. | operator failed. failed to get first value: %v\n", err)
 		}
 
-		err = ip.push(v3)
+		v2, err := ip.pop()
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. 2swap operator failed. failure pushing value: %v", err)
-		}
-		if s3, ok := v3.String(); ok {
-			ip.runtimev("pushed \"%s\"\n", s3)
-		} else if n3, ok := v3.Int(); ok {
-			ip.runtimev("pushed %d\n", n3)
-		} else if p3, ok := v3.Path(); ok {
-			ip.runtimev("pushed %s\n", p3)
-		}
-	} else if token.Equals("if", types.TokenTypeKeyword) {
-		if ip.stack.Len() < 1 {
-			return ip.runtimeverr("failed to run step. if operator failed. stack is empty.\n")
+			return ip.runtimeverr("failed to run step. | operator failed. failed to get second value: %v\n", err)
 		}
 
-		ip.runtimev("validating if-condition.\n")
-		v1, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. if operator failed. failed to get condition value: %v\n", err)
+		if v2.IsString() {
+			return ip.runtimeverr("failed to run step. | operator failed. cannot bitwise or string.\n")
 		}
 
-		var truthy bool = false
-		if s1, ok := v1.String(); ok {
-			ip.runtimev("popped \"%s\"\n", s1)
-			truthy = len(s1) > 0
-		} else if n1, ok := v1.Int(); ok {
-			ip.runtimev("popped %d\n", n1)
-			truthy = n1 != 0
+		if v1.IsString() {
+			return ip.runtimeverr("failed to run step. | operator failed. cannot bitwise or string.\n")
 		}
 
-		if !truthy {
-			ip.ip = int(inst.Next)
-			return true, nil
-		}
-	} else if token.Equals("unless", types.TokenTypeKeyword) {
-		if ip.stack.Len() < 1 {
-			return ip.runtimeverr("failed to run step. unless operator failed. stack is empty.\n")
+		n1, ok := v1.Int()
+		if !ok {
+			return ip.runtimeverr("failed to run step. | operator failed. failed to get first value type.\n")
 		}
+		ip.runtimev("popped %d\n", n1)
 
-		ip.runtimev("validating unless-condition.\n")
-		v1, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. unless operator failed. failed to get condition value: %v\n", err)
+		n2, ok := v2.Int()
+		if !ok {
+			return ip.runtimeverr("failed to run step. | operator failed. failed to get second value type.\n")
 		}
+		ip.runtimev("popped %d\n", n2)
 
-		var truthy bool = false
-		if s1, ok := v1.String(); ok {
-			ip.runtimev("popped \"%s\"\n", s1)
-			truthy = len(s1) > 0
-		} else if n1, ok := v1.Int(); ok {
-			ip.runtimev("popped %d\n", n1)
-			truthy = n1 != 0
-		}
+		result := n2 | n1
 
-		if truthy {
-			ip.ip = int(inst.Next)
-			return true, nil
-		}
-	} else if token.Equals("else", types.TokenTypeKeyword) {
-		if inst.Next != -1 {
-			ip.ip = int(inst.Next)
-			return true, nil
+		err = ip.ipush(result)
+		if err != nil {
+			return false, fmt.Errorf("failed to run step. | operator failed. failure pushing value: %v", err)
 		}
-	} else if token.Equals("do", types.TokenTypeKeyword) {
-		if ip.stack.Len() < 1 {
-			return ip.runtimeverr("failed to run step. do operator failed. stack is empty.\n")
+		ip.runtimev("pushed %d\n", result)
+	} else if token.Equals("^", types.TokenTypeSymbol) {
+		if ip.stack.Len() < 2 {
+			return ip.runtimeverr("failed to run step. ^ operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
 		}
 
-		ip.runtimev("validating do-condition.\n")
+		ip.runtimev("bitwisexor two numbers.\n")
 		v1, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. do operator failed. failed to get condition value: %v\n", err)
+			return ip.runtimeverr("failed to run step. ^ operator failed. failed to get first value: %v\n", err)
 		}
 
-		var truthy bool = false
-		if s1, ok := v1.String(); ok {
-			ip.runtimev("popped \"%s\"\n", s1)
-			truthy = len(s1) > 0
-		} else if n1, ok := v1.Int(); ok {
-			ip.runtimev("popped %d\n", n1)
-			truthy = n1 != 0
+		v2, err := ip.pop()
+		if err != nil {
+			return ip.runtimeverr("failed to run step. ^ operator failed. failed to get second value: %v\n", err)
 		}
 
-		if inst.Mode == DoModeUntil {
-			truthy = !truthy
+		if v2.IsString() {
+			return ip.runtimeverr("failed to run step. ^ operator failed. cannot bitwise xor string.\n")
 		}
 
-		if !truthy {
-			ip.ip = int(inst.Next)
-			return true, nil
-		}
-	} else if token.Equals("end", types.TokenTypeKeyword) {
-		if inst.Next != -1 {
-			ip.ip = int(inst.Next)
-			return true, nil
-		}
-	} else if token.Equals("while", types.TokenTypeKeyword) {
-		ip.runtimev("while (do nothing).\n")
-	} else if token.Equals("until", types.TokenTypeKeyword) {
-		ip.runtimev("until (do nothing).\n")
-	} else if token.Equals("=", types.TokenTypeSymbol) {
-		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. = operator failed. stack size is %d. 2 is reqired.\n", ip.stack.Len())
+		if v1.IsString() {
+			return ip.runtimeverr("failed to run step. ^ operator failed. cannot bitwise xor string.\n")
 		}
 
-		ip.runtimev("equality-check top stack values.\n")
-		v1, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. = operator failed. failed to get first value: %v\n", err)
+		n1, ok := v1.Int()
+		if !ok {
+			return ip.runtimeverr("failed to run step. ^ operator failed. failed to get first value type.\n")
 		}
+		ip.runtimev("popped %d\n", n1)
 
-		v2, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. = operator failed. failed to get second value: %v\n", err)
+		n2, ok := v2.Int()
+		if !ok {
+			return ip.runtimeverr("failed to run step. ^ operator failed. failed to get second value type.\n")
 		}
+		ip.runtimev("popped %d\n", n2)
 
-		var result int = 0
-		var name string = "false"
-
-		if s1, ok := v1.String(); ok {
-			if s2, ok := v2.String(); ok {
-				ip.runtimev("popped \"%s\"\n", s1)
-				ip.runtimev("popped \"%s\"\n", s2)
-				if s1 == s2 {
-					result = 1
-					name = "true"
-				}
-			} else {
-				ip.runtimev("popped int\n")
-				ip.runtimev("popped \"%s\"\n", s1)
-			}
-		} else if n1, ok := v1.Int(); ok {
-			if n2, ok := v2.Int(); ok {
-				ip.runtimev("popped %d\n", n1)
-				ip.runtimev("popped %d\n", n2)
-				if n1 == n2 {
-					result = 1
-					name = "true"
-				}
-			} else {
-				ip.runtimev("popped string\n")
-				ip.runtimev("popped %d\n", n1)
-			}
-		}
+		result := n2 ^ n1
 
 		err = ip.ipush(result)
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. = operator failed. failure pushing value: %v", err)
+			return false, fmt.Errorf("failed to run step. ^ operator failed. failure pushing value: %v", err)
 		}
-		ip.runtimev("pushed %s\n", name)
-	} else if token.Equals("!=", types.TokenTypeSymbol) {
+		ip.runtimev("pushed %d\n", result)
+	} else if token.Equals("&&", types.TokenTypeSymbol) {
 		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. != operator failed. stack size is %d. 2 is reqired.\n", ip.stack.Len())
+			return ip.runtimeverr("failed to run step. && operator failed. stack size is %d. 2 is reqired.\n", ip.stack.Len())
 		}
 
-		ip.runtimev("inequality-check top stack values.\n")
+		ip.runtimev("logicaland top stack values.\n")
 		v1, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. != operator failed. failed to get first value: %v\n", err)
+			return ip.runtimeverr("failed to run step. && operator failed. failed to get first value: %v\n", err)
 		}
 
 		v2, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. != operator failed. failed to get second value: %v\n", err)
+			return ip.runtimeverr("failed to run step. && operator failed. failed to get second value: %v\n", err)
 		}
 
-		var result int = 1
-		var name string = "true"
+		ip.runtimev("popped %s\n", v1.Format())
+		ip.runtimev("popped %s\n", v2.Format())
+		truthy1 := v1.Truthy()
+		truthy2 := v2.Truthy()
 
-		if s1, ok := v1.String(); ok {
-			if s2, ok := v2.String(); ok {
-				ip.runtimev("popped \"%s\"\n", s1)
-				ip.runtimev("popped \"%s\"\n", s2)
-				if s1 == s2 {
-					result = 0
-					name = "false"
-				}
-			} else {
-				ip.runtimev("popped int\n")
-				ip.runtimev("popped \"%s\"\n", s1)
-			}
-		} else if n1, ok := v1.Int(); ok {
-			if n2, ok := v2.Int(); ok {
-				ip.runtimev("popped %d\n", n1)
-				ip.runtimev("popped %d\n", n2)
-				if n1 == n2 {
-					result = 0
-					name = "false"
-				}
-			} else {
-				ip.runtimev("popped string\n")
-				ip.runtimev("popped %d\n", n1)
-			}
-		}
+		result := truthy1 && truthy2
 
-		err = ip.ipush(result)
+		err = ip.bpush(result)
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. != operator failed. failure pushing value: %v", err)
+			return false, fmt.Errorf("failed to run step. && operator failed. failure pushing value: %v", err)
 		}
-		ip.runtimev("pushed %s\n", name)
-	} else if token.Equals("<", types.TokenTypeSymbol) {
+		ip.runtimev("pushed %t\n", result)
+	} else if token.Equals("||", types.TokenTypeSymbol) {
 		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. < operator failed. stack size is %d. 2 is reqired.\n", ip.stack.Len())
+			return ip.runtimeverr("failed to run step. || operator failed. stack size is %d. 2 is reqired.\n", ip.stack.Len())
 		}
 
-		ip.runtimev("less-check top stack values.\n")
+		ip.runtimev("logicalor top stack values.\n")
 		v1, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. < operator failed. failed to get first value: %v\n", err)
+			return ip.runtimeverr("failed to run step. || operator failed. failed to get first value: %v\n", err)
 		}
 
 		v2, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. < operator failed. failed to get second value: %v\n", err)
+			return ip.runtimeverr("failed to run step. || operator failed. failed to get second value: %v\n", err)
 		}
 
-		var result int = 0
-		var name string = "false"
+		ip.runtimev("popped %s\n", v1.Format())
+		ip.runtimev("popped %s\n", v2.Format())
+		truthy1 := v1.Truthy()
+		truthy2 := v2.Truthy()
 
-		if s1, ok := v1.String(); ok {
-			if s2, ok := v2.String(); ok {
-				ip.runtimev("popped \"%s\"\n", s1)
-				ip.runtimev("popped \"%s\"\n", s2)
-				if s2 < s1 {
-					result = 1
-					name = "true"
-				}
-			} else {
-				return ip.runtimeverr("failed to run step. < operator failed. cannot compare string and int.\n")
-			}
-		} else if n1, ok := v1.Int(); ok {
-			if n2, ok := v2.Int(); ok {
-				ip.runtimev("popped %d\n", n1)
-				ip.runtimev("popped %d\n", n2)
-				if n2 < n1 {
-					result = 1
-					name = "true"
-				}
-			} else {
-				return ip.runtimeverr("failed to run step. < operator failed. cannot compare int and string.\n")
-			}
-		}
+		result := truthy1 || truthy2
 
-		err = ip.ipush(result)
+		err = ip.bpush(result)
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. < operator failed. failure pushing value: %v", err)
-		}
-		ip.runtimev("pushed %s\n", name)
-	} else if token.Equals(">", types.TokenTypeSymbol) {
-		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. > operator failed. stack size is %d. 2 is reqired.\n", ip.stack.Len())
+			return false, fmt.Errorf("failed to run step. || operator failed. failure pushing value: %v", err)
 		}
-
-		ip.runtimev("greater-check top stack values.\n")
-		v1, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. > operator failed. failed to get first value: %v\n", err)
+		ip.runtimev("pushed %t\n", result)
+	} else if token.Equals("[", types.TokenTypeSymbol) {
+		ip.runtimev("list start at stack size %d.\n", ip.stack.Len())
+		ip.marks.Push(ip.stack.Len())
+	} else if token.Equals("]", types.TokenTypeSymbol) {
+		mark, ok := ip.marks.Pop()
+		if !ok {
+			return ip.runtimeverr("failed to run step. ] operator failed. no list was started.\n")
 		}
 
-		v2, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. > operator failed. failed to get second value: %v\n", err)
+		if ip.stack.Len() < mark {
+			return ip.runtimeverr("failed to run step. ] operator failed. stack size %d dropped below list start %d.\n", ip.stack.Len(), mark)
 		}
 
-		var result int = 0
-		var name string = "false"
-
-		if s1, ok := v1.String(); ok {
-			if s2, ok := v2.String(); ok {
-				ip.runtimev("popped \"%s\"\n", s1)
-				ip.runtimev("popped \"%s\"\n", s2)
-				if s2 > s1 {
-					result = 1
-					name = "true"
-				}
-			} else {
-				return ip.runtimeverr("failed to run step. > operator failed. cannot compare string and int.\n")
-			}
-		} else if n1, ok := v1.Int(); ok {
-			if n2, ok := v2.Int(); ok {
-				ip.runtimev("popped %d\n", n1)
-				ip.runtimev("popped %d\n", n2)
-				if n2 > n1 {
-					result = 1
-					name = "true"
-				}
-			} else {
-				return ip.runtimeverr("failed to run step. > operator failed. cannot compare int and string.\n")
+		items := make([]StackValue, ip.stack.Len() - mark)
+		for idx := len(items) - 1; idx >= 0; idx-- {
+			item, err := ip.pop()
+			if err != nil {
+				return ip.runtimeverr("failed to run step. ] operator failed. failed to get item: %v\n", err)
 			}
+			items[idx] = item
 		}
 
-		err = ip.ipush(result)
+		result := StackList(items)
+		err := ip.push(result)
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. > operator failed. failure pushing value: %v", err)
+			return false, fmt.Errorf("failed to run step. ] operator failed. failure pushing value: %v", err)
 		}
-		ip.runtimev("pushed %s\n", name)
-	} else if token.Equals("<=", types.TokenTypeSymbol) {
-		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. <= operator failed. stack size is %d. 2 is reqired.\n", ip.stack.Len())
+		ip.runtimev("pushed %s\n", result.Format())
+	} else if token.Equals("{", types.TokenTypeSymbol) {
+		ip.runtimev("map start at stack size %d.\n", ip.stack.Len())
+		ip.marks.Push(ip.stack.Len())
+	} else if token.Equals("}", types.TokenTypeSymbol) {
+		mark, ok := ip.marks.Pop()
+		if !ok {
+			return ip.runtimeverr("failed to run step. } operator failed. no map was started.\n")
 		}
 
-		ip.runtimev("lessorequal-check top stack values.\n")
-		v1, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. <= operator failed. failed to get first value: %v\n", err)
+		if ip.stack.Len() < mark {
+			return ip.runtimeverr("failed to run step. } operator failed. stack size %d dropped below map start %d.\n", ip.stack.Len(), mark)
 		}
 
-		v2, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. <= operator failed. failed to get second value: %v\n", err)
+		count := ip.stack.Len() - mark
+		if count % 2 != 0 {
+			return ip.runtimeverr("failed to run step. } operator failed. map literal holds %d values, expected key and value pairs.\n", count)
 		}
 
-		var result int = 0
-		var name string = "false"
-
-		if s1, ok := v1.String(); ok {
-			if s2, ok := v2.String(); ok {
-				ip.runtimev("popped \"%s\"\n", s1)
-				ip.runtimev("popped \"%s\"\n", s2)
-				if s2 <= s1 {
-					result = 1
-					name = "true"
-				}
-			} else {
-				return ip.runtimeverr("failed to run step. <= operator failed. cannot compare string and int.\n")
+		items := make([]StackValue, count)
+		for idx := count - 1; idx >= 0; idx-- {
+			item, err := ip.pop()
+			if err != nil {
+				return ip.runtimeverr("failed to run step. } operator failed. failed to get item: %v\n", err)
 			}
-		} else if n1, ok := v1.Int(); ok {
-			if n2, ok := v2.Int(); ok {
-				ip.runtimev("popped %d\n", n1)
-				ip.runtimev("popped %d\n", n2)
-				if n2 <= n1 {
-					result = 1
-					name = "true"
-				}
-			} else {
-				return ip.runtimeverr("failed to run step. <= operator failed. cannot compare int and string.\n")
+			items[idx] = item
+		}
+
+		entries := make(map[string]StackValue, count / 2)
+		for idx := 0; idx < count; idx += 2 {
+			key, ok := items[idx].String()
+			if !ok {
+				return ip.runtimeverr("failed to run step. } operator failed. key is %s, not a string.\n", items[idx].Kind())
 			}
+
+			entries[key] = items[idx + 1]
 		}
 
-		err = ip.ipush(result)
+		result := StackMap(entries)
+		err := ip.push(result)
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. <= operator failed. failure pushing value: %v", err)
+			return false, fmt.Errorf("failed to run step. } operator failed. failure pushing value: %v", err)
 		}
-		ip.runtimev("pushed %s\n", name)
-	} else if token.Equals(">=", types.TokenTypeSymbol) {
+		ip.runtimev("pushed %s\n", result.Format())
+	} else if token.Equals("get", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. >= operator failed. stack size is %d. 2 is reqired.\n", ip.stack.Len())
+			return ip.runtimeverr("failed to run step. get operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
 		}
 
-		ip.runtimev("greaterorequal-check top stack values.\n")
-		v1, err := ip.pop()
+		ip.runtimev("getting map entry.\n")
+		vKey, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. >= operator failed. failed to get first value: %v\n", err)
+			return ip.runtimeverr("failed to run step. get operator failed. failed to get key: %v\n", err)
 		}
 
-		v2, err := ip.pop()
+		key, ok := vKey.String()
+		if !ok {
+			return ip.runtimeverr("failed to run step. get operator failed. key is %s, not a string.\n", vKey.Kind())
+		}
+
+		vMap, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. >= operator failed. failed to get second value: %v\n", err)
+			return ip.runtimeverr("failed to run step. get operator failed. failed to get map: %v\n", err)
 		}
 
-		var result int = 0
-		var name string = "false"
+		entries, ok := vMap.Map()
+		if !ok {
+			return ip.runtimeverr("failed to run step. get operator failed. value is %s, not a map.\n", vMap.Kind())
+		}
 
-		if s1, ok := v1.String(); ok {
-			if s2, ok := v2.String(); ok {
-				ip.runtimev("popped \"%s\"\n", s1)
-				ip.runtimev("popped \"%s\"\n", s2)
-				if s2 >= s1 {
-					result = 1
-					name = "true"
-				}
-			} else {
-				return ip.runtimeverr("failed to run step. >= operator failed. cannot compare string and int.\n")
+		value, found := entries[key]
+		if !found {
+			ip.runtimev("key '%s' not found\n", key)
+			err = ip.bpush(false)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. get operator failed. failure pushing value: %v", err)
 			}
-		} else if n1, ok := v1.Int(); ok {
-			if n2, ok := v2.Int(); ok {
-				ip.runtimev("popped %d\n", n1)
-				ip.runtimev("popped %d\n", n2)
-				if n2 >= n1 {
-					result = 1
-					name = "true"
-				}
-			} else {
-				return ip.runtimeverr("failed to run step. >= operator failed. cannot compare int and string.\n")
+		} else {
+			err = ip.push(value)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. get operator failed. failure pushing value: %v", err)
 			}
-		}
+			ip.runtimev("pushed %s\n", value.Format())
 
-		err = ip.ipush(result)
-		if err != nil {
-			return false, fmt.Errorf("failed to run step. >= operator failed. failure pushing value: %v", err)
+			err = ip.bpush(true)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. get operator failed. failure pushing value: %v", err)
+			}
 		}
-		ip.runtimev("pushed %s\n", name)
-	} else if token.Equals("!", types.TokenTypeSymbol) {
-		if ip.stack.Len() < 1 {
-			return ip.runtimeverr("failed to run step. ! operator failed. stack is empty.\n")
+	} else if token.Equals("set", types.TokenTypeKeyword) {
+		if ip.stack.Len() < 3 {
+			return ip.runtimeverr("failed to run step. set operator failed. stack size is %d. 3 is required.\n", ip.stack.Len())
 		}
 
-		ip.runtimev("logicalnot top stack value.\n")
-		v1, err := ip.pop()
+		ip.runtimev("setting map entry.\n")
+		vValue, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. ! operator failed. failed to get value: %v\n", err)
+			return ip.runtimeverr("failed to run step. set operator failed. failed to get value: %v\n", err)
 		}
 
-		var truthy bool = false
-		if s1, ok := v1.String(); ok {
-			ip.runtimev("popped \"%s\"\n", s1)
-			truthy = len(s1) > 0
-		} else if n1, ok := v1.Int(); ok {
-			ip.runtimev("popped %d\n", n1)
-			truthy = n1 != 0
+		vKey, err := ip.pop()
+		if err != nil {
+			return ip.runtimeverr("failed to run step. set operator failed. failed to get key: %v\n", err)
 		}
 
-		var result int = 0
-		var name string = "false"
-		if !truthy {
-			result = 1
-			name = "true"
+		key, ok := vKey.String()
+		if !ok {
+			return ip.runtimeverr("failed to run step. set operator failed. key is %s, not a string.\n", vKey.Kind())
 		}
 
-		err = ip.ipush(result)
+		vMap, err := ip.pop()
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. ! operator failed. failure pushing value: %v", err)
+			return ip.runtimeverr("failed to run step. set operator failed. failed to get map: %v\n", err)
 		}
-		ip.runtimev("pushed %s\n", name)
-	} else if token.Equals("~", types.TokenTypeSymbol) {
-		if ip.stack.Len() < 1 {
-			return ip.runtimeverr("failed to run step. ~ operator failed. stack is empty.\n")
+
+		entries, ok := vMap.Map()
+		if !ok {
+			return ip.runtimeverr("failed to run step. set operator failed. value is %s, not a map.\n", vMap.Kind())
 		}
 
-		ip.runtimev("bitwisenot top stack value.\n")
-		v1, err := ip.pop()
+		// Maps are values, so the entry goes into a copy rather than every holder of the map.
+		updated := maps.Clone(entries)
+		if updated == nil {
+			updated = make(map[string]StackValue)
+		}
+		updated[key] = vValue
+
+		result := StackMap(updated)
+		err = ip.push(result)
 		if err != nil {
-			return ip.runtimeverr("failed to run step. ~ operator failed. failed to get value: %v\n", err)
+			return false, fmt.Errorf("failed to run step. set operator failed. failure pushing value: %v", err)
+		}
+		ip.runtimev("pushed %s\n", result.Format())
+	} else if token.Equals("has", types.TokenTypeKeyword) {
+		if ip.stack.Len() < 2 {
+			return ip.runtimeverr("failed to run step. has operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
 		}
 
-		if v1.IsString() {
-			return ip.runtimeverr("failed to run step. ~ operator failed. cannot bitwise not string.\n")
+		ip.runtimev("checking map entry.\n")
+		vKey, err := ip.pop()
+		if err != nil {
+			return ip.runtimeverr("failed to run step. has operator failed. failed to get key: %v\n", err)
 		}
 
-		n1, ok := v1.Int()
+		key, ok := vKey.String()
 		if !ok {
-			return ip.runtimeverr("failed to run step. ~ operator failed. failed to get value type.\n")
+			return ip.runtimeverr("failed to run step. has operator failed. key is %s, not a string.\n", vKey.Kind())
 		}
-		ip.runtimev("popped %d\n", n1)
 
-		result := ^n1
-
-		err = ip.ipush(result)
+		vMap, err := ip.pop()
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. ~ operator failed. failure pushing value: %v", err)
+			return ip.runtimeverr("failed to run step. has operator failed. failed to get map: %v\n", err)
 		}
-		ip.runtimev("pushed %d\n", result)
-	} else if token.Equals("&", types.TokenTypeSymbol) {
-		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. & operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
+
+		entries, ok := vMap.Map()
+		if !ok {
+			return ip.runtimeverr("failed to run step. has operator failed. value is %s, not a map.\n", vMap.Kind())
 		}
 
-		ip.runtimev("bitwiseand two numbers.\n")
-		v1, err := ip.pop()
+		_, result := entries[key]
+		err = ip.bpush(result)
 		if err != nil {
-			return ip.runtimeverr("failed to run step. & operator failed. failed to get first value: %v\n", err)
+			return false, fmt.Errorf("failed to run step. has operator failed. failure pushing value: %v", err)
+		}
+		ip.runtimev("pushed %t\n", result)
+	} else if token.Equals("keys", types.TokenTypeKeyword) {
+		if ip.stack.Len() < 1 {
+			return ip.runtimeverr("failed to run step. keys operator failed. stack is empty.\n")
 		}
 
-		v2, err := ip.pop()
+		ip.runtimev("listing map keys.\n")
+		vMap, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. & operator failed. failed to get second value: %v\n", err)
+			return ip.runtimeverr("failed to run step. keys operator failed. failed to get map: %v\n", err)
 		}
 
-		if v2.IsString() {
-			return ip.runtimeverr("failed to run step. & operator failed. cannot bitwise and string.\n")
+		entries, ok := vMap.Map()
+		if !ok {
+			return ip.runtimeverr("failed to run step. keys operator failed. value is %s, not a map.\n", vMap.Kind())
 		}
 
-		if v1.IsString() {
-			return ip.runtimeverr("failed to run step. & operator failed. cannot bitwise and string.\n")
+		result := StackList(stringList(sortedKeys(entries)))
+		err = ip.push(result)
+		if err != nil {
+			return false, fmt.Errorf("failed to run step. keys operator failed. failure pushing value: %v", err)
 		}
-
-		n1, ok := v1.Int()
-		if !ok {
-			return ip.runtimeverr("failed to run step. & operator failed. failed to get first value type.\n")
+		ip.runtimev("pushed %s\n", result.Format())
+	} else if token.Equals("len", types.TokenTypeKeyword) {
+		if ip.stack.Len() < 1 {
+			return ip.runtimeverr("failed to run step. len operator failed. stack is empty.\n")
 		}
-		ip.runtimev("popped %d\n", n1)
 
-		n2, ok := v2.Int()
-		if !ok {
-			return ip.runtimeverr("failed to run step. & operator failed. failed to get second value type.\n")
+		ip.runtimev("len of top value.\n")
+		v1, err := ip.pop()
+		if err != nil {
+			return ip.runtimeverr("failed to run step. len operator failed. failed to get value: %v\n", err)
 		}
-		ip.runtimev("popped %d\n", n2)
+		ip.runtimev("popped %s\n", v1.Format())
 
-		result := n2 & n1
+		var result int
+		if l1, ok := v1.List(); ok {
+			result = len(l1)
+		} else if m1, ok := v1.Map(); ok {
+			result = len(m1)
+		} else if s1, ok := v1.String(); ok {
+			result = len(s1)
+		} else {
+			return ip.runtimeverr("failed to run step. len operator failed. cannot measure %s.\n", v1.Kind())
+		}
 
 		err = ip.ipush(result)
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. & operator failed. failure pushing value: %v", err)
+			return false, fmt.Errorf("failed to run step. len operator failed. failure pushing value: %v", err)
 		}
 		ip.runtimev("pushed %d\n", result)
-	} else if token.Equals("|", types.TokenTypeSymbol) {
+	} else if token.Equals("nth", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. | operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
+			return ip.runtimeverr("failed to run step. nth operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
 		}
 
-		ip.runtimev("bitwiseor two numbers.\n")
-		v1, err := ip.pop()
+		ip.runtimev("indexing list.\n")
+		vIdx, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. | operator failed. failed to get first value: %v\n", err)
+			return ip.runtimeverr("failed to run step. nth operator failed. failed to get index: %v\n", err)
 		}
 
-		v2, err := ip.pop()
+		idx, ok := vIdx.Int()
+		if !ok {
+			return ip.runtimeverr("failed to run step. nth operator failed. index is %s, not an int.\n", vIdx.Kind())
+		}
+
+		vList, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. | operator failed. failed to get second value: %v\n", err)
+			return ip.runtimeverr("failed to run step. nth operator failed. failed to get list: %v\n", err)
 		}
 
-		if v2.IsString() {
-			return ip.runtimeverr("failed to run step. | operator failed. cannot bitwise or string.\n")
+		list, ok := vList.List()
+		if !ok {
+			return ip.runtimeverr("failed to run step. nth operator failed. value is %s, not a list.\n", vList.Kind())
 		}
 
-		if v1.IsString() {
-			return ip.runtimeverr("failed to run step. | operator failed. cannot bitwise or string.\n")
+		if idx < 0 || idx >= len(list) {
+			ip.runtimev("index %d out of bounds(%d)\n", idx, len(list))
+			err = ip.bpush(false)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. nth operator failed. failure pushing value: %v", err)
+			}
+		} else {
+			err = ip.push(list[idx])
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. nth operator failed. failure pushing value: %v", err)
+			}
+			ip.runtimev("pushed %s\n", list[idx].Format())
+
+			err = ip.bpush(true)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. nth operator failed. failure pushing value: %v", err)
+			}
+		}
+	} else if token.Equals("argc", types.TokenTypeKeyword) {
+		ip.runtimev("counting script arguments.\n")
+		err := ip.ipush(len(scriptArgs))
+		if err != nil {
+			return false, fmt.Errorf("failed to run step. argc operator failed. failure pushing value: %v", err)
+		}
+		ip.runtimev("pushed %d\n", len(scriptArgs))
+	} else if token.Equals("argv", types.TokenTypeKeyword) {
+		if ip.stack.Len() < 1 {
+			return ip.runtimeverr("failed to run step. argv operator failed. stack is empty.\n")
 		}
 
-		n1, ok := v1.Int()
-		if !ok {
-			return ip.runtimeverr("failed to run step. | operator failed. failed to get first value type.\n")
+		ip.runtimev("indexing script arguments.\n")
+		vIdx, err := ip.pop()
+		if err != nil {
+			return ip.runtimeverr("failed to run step. argv operator failed. failed to get index: %v\n", err)
 		}
-		ip.runtimev("popped %d\n", n1)
 
-		n2, ok := v2.Int()
+		idx, ok := vIdx.Int()
 		if !ok {
-			return ip.runtimeverr("failed to run step. | operator failed. failed to get second value type.\n")
+			return ip.runtimeverr("failed to run step. argv operator failed. index is %s, not an int.\n", vIdx.Kind())
 		}
-		ip.runtimev("popped %d\n", n2)
 
-		result := n2 | n1
+		if idx < 0 || idx >= len(scriptArgs) {
+			ip.runtimev("index %d out of bounds(%d)\n", idx, len(scriptArgs))
+			err = ip.bpush(false)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. argv operator failed. failure pushing value: %v", err)
+			}
+		} else {
+			err = ip.spush(scriptArgs[idx])
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. argv operator failed. failure pushing value: %v", err)
+			}
+			ip.runtimev("pushed %q\n", scriptArgs[idx])
 
-		err = ip.ipush(result)
+			err = ip.bpush(true)
+			if err != nil {
+				return false, fmt.Errorf("failed to run step. argv operator failed. failure pushing value: %v", err)
+			}
+		}
+	} else if token.Equals("args", types.TokenTypeKeyword) {
+		ip.runtimev("listing script arguments.\n")
+		result := StackList(stringList(scriptArgs))
+		err := ip.push(result)
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. | operator failed. failure pushing value: %v", err)
+			return false, fmt.Errorf("failed to run step. args operator failed. failure pushing value: %v", err)
 		}
-		ip.runtimev("pushed %d\n", result)
-	} else if token.Equals("^", types.TokenTypeSymbol) {
+		ip.runtimev("pushed %s\n", result.Format())
+	} else if token.Equals("append", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. ^ operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
+			return ip.runtimeverr("failed to run step. append operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
 		}
 
-		ip.runtimev("bitwisexor two numbers.\n")
-		v1, err := ip.pop()
+		ip.runtimev("appending to list.\n")
+		vItem, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. ^ operator failed. failed to get first value: %v\n", err)
+			return ip.runtimeverr("failed to run step. append operator failed. failed to get item: %v\n", err)
 		}
 
-		v2, err := ip.pop()
+		vList, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. ^ operator failed. failed to get second value: %v\n", err)
-		}
-
-		if v2.IsString() {
-			return ip.runtimeverr("failed to run step. ^ operator failed. cannot bitwise xor string.\n")
-		}
-
-		if v1.IsString() {
-			return ip.runtimeverr("failed to run step. ^ operator failed. cannot bitwise xor string.\n")
-		}
-
-		n1, ok := v1.Int()
-		if !ok {
-			return ip.runtimeverr("failed to run step. ^ operator failed. failed to get first value type.\n")
+			return ip.runtimeverr("failed to run step. append operator failed. failed to get list: %v\n", err)
 		}
-		ip.runtimev("popped %d\n", n1)
 
-		n2, ok := v2.Int()
+		list, ok := vList.List()
 		if !ok {
-			return ip.runtimeverr("failed to run step. ^ operator failed. failed to get second value type.\n")
+			return ip.runtimeverr("failed to run step. append operator failed. value is %s, not a list.\n", vList.Kind())
 		}
-		ip.runtimev("popped %d\n", n2)
-
-		result := n2 ^ n1
 
-		err = ip.ipush(result)
+		// Clipping forces a copy, so lists sharing a backing array never see each other's items.
+		result := StackList(append(slices.Clip(list), vItem))
+		err = ip.push(result)
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. ^ operator failed. failure pushing value: %v", err)
+			return false, fmt.Errorf("failed to run step. append operator failed. failure pushing value: %v", err)
 		}
-		ip.runtimev("pushed %d\n", result)
-	} else if token.Equals("&&", types.TokenTypeSymbol) {
+		ip.runtimev("pushed %s\n", result.Format())
+	} else if token.Equals("join", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. && operator failed. stack size is %d. 2 is reqired.\n", ip.stack.Len())
+			return ip.runtimeverr("failed to run step. join operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
 		}
 
-		ip.runtimev("logicaland top stack values.\n")
-		v1, err := ip.pop()
+		ip.runtimev("joining list.\n")
+		vSep, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. && operator failed. failed to get first value: %v\n", err)
+			return ip.runtimeverr("failed to run step. join operator failed. failed to get separator: %v\n", err)
 		}
 
-		v2, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. && operator failed. failed to get second value: %v\n", err)
+		sep, ok := vSep.String()
+		if !ok {
+			return ip.runtimeverr("failed to run step. join operator failed. separator is %s, not a string.\n", vSep.Kind())
 		}
 
-		var truthy1 bool = false
-		var truthy2 bool = false
-
-		if s1, ok := v1.String(); ok {
-			ip.runtimev("popped \"%s\"\n", s1)
-			truthy1 = len(s1) > 0
-		} else if n1, ok := v1.Int(); ok {
-			ip.runtimev("popped %d\n", n1)
-			truthy1 = n1 != 0
+		vList, err := ip.pop()
+		if err != nil {
+			return ip.runtimeverr("failed to run step. join operator failed. failed to get list: %v\n", err)
 		}
 
-		if s2, ok := v2.String(); ok {
-			ip.runtimev("popped \"%s\"\n", s2)
-			truthy2 = len(s2) > 0
-		} else if n2, ok := v2.Int(); ok {
-			ip.runtimev("popped %d\n", n2)
-			truthy2 = n2 != 0
+		list, ok := vList.List()
+		if !ok {
+			return ip.runtimeverr("failed to run step. join operator failed. value is %s, not a list.\n", vList.Kind())
 		}
 
-		var result int = 0
-		var name string = "false"
-		if truthy1 && truthy2 {
-			result = 1
-			name = "true"
+		parts := make([]string, len(list))
+		for idx, item := range list {
+			parts[idx] = toString(item)
 		}
 
-		err = ip.ipush(result)
+		result := strings.Join(parts, sep)
+		err = ip.spush(result)
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. && operator failed. failure pushing value: %v", err)
+			return false, fmt.Errorf("failed to run step. join operator failed. failure pushing value: %v", err)
 		}
-		ip.runtimev("pushed %s\n", name)
-	} else if token.Equals("||", types.TokenTypeSymbol) {
+		ip.runtimev("pushed \"%s\"\n", result)
+	} else if token.Equals("split", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 2 {
-			return ip.runtimeverr("failed to run step. || operator failed. stack size is %d. 2 is reqired.\n", ip.stack.Len())
-		}
-
-		ip.runtimev("logicalor top stack values.\n")
-		v1, err := ip.pop()
-		if err != nil {
-			return ip.runtimeverr("failed to run step. || operator failed. failed to get first value: %v\n", err)
+			return ip.runtimeverr("failed to run step. split operator failed. stack size is %d. 2 is required.\n", ip.stack.Len())
 		}
 
-		v2, err := ip.pop()
+		ip.runtimev("splitting string.\n")
+		vSep, err := ip.pop()
 		if err != nil {
-			return ip.runtimeverr("failed to run step. || operator failed. failed to get second value: %v\n", err)
+			return ip.runtimeverr("failed to run step. split operator failed. failed to get separator: %v\n", err)
 		}
 
-		var truthy1 bool = false
-		var truthy2 bool = false
-
-		if s1, ok := v1.String(); ok {
-			ip.runtimev("popped \"%s\"\n", s1)
-			truthy1 = len(s1) > 0
-		} else if n1, ok := v1.Int(); ok {
-			ip.runtimev("popped %d\n", n1)
-			truthy1 = n1 != 0
+		sep, ok := vSep.String()
+		if !ok {
+			return ip.runtimeverr("failed to run step. split operator failed. separator is %s, not a string.\n", vSep.Kind())
 		}
 
-		if s2, ok := v2.String(); ok {
-			ip.runtimev("popped \"%s\"\n", s2)
-			truthy2 = len(s2) > 0
-		} else if n2, ok := v2.Int(); ok {
-			ip.runtimev("popped %d\n", n2)
-			truthy2 = n2 != 0
+		vStr, err := ip.pop()
+		if err != nil {
+			return ip.runtimeverr("failed to run step. split operator failed. failed to get string: %v\n", err)
 		}
 
-		var result int = 0
-		var name string = "false"
-		if truthy1 || truthy2 {
-			result = 1
-			name = "true"
+		str, ok := vStr.String()
+		if !ok {
+			return ip.runtimeverr("failed to run step. split operator failed. value is %s, not a string.\n", vStr.Kind())
 		}
 
-		err = ip.ipush(result)
+		result := StackList(stringList(strings.Split(str, sep)))
+		err = ip.push(result)
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. || operator failed. failure pushing value: %v", err)
+			return false, fmt.Errorf("failed to run step. split operator failed. failure pushing value: %v", err)
 		}
-		ip.runtimev("pushed %s\n", name)
+		ip.runtimev("pushed %s\n", result.Format())
 	} else if token.Equals("download", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 2 {
 			return ip.runtimeverr("failed to run step. download command failed. stack size is %d. 2 is required.\n", ip.stack.Len())
@@ -1625,20 +1651,38 @@ func (ip *Interpreter) Step() (bool, error) {
 		}
 
 
-		var result int = 1
+		var result bool = true
 		err = tools.ToolDownload(sUrl, pDst)
 		if err != nil {
 			ip.runtimev("failed to use download tool: %v\n", err)
-			result = 0
+			result = false
 		}
 
-		err = ip.ipush(result)
+		err = ip.bpush(result)
 		if err != nil {
 			return false, fmt.Errorf("failed to run step. download command failed. failure pushing value: %v", err)
 		}
+	} else if token.Equals("downloadwith", types.TokenTypeKeyword) {
+		ip.runtimev("downloadwith command.\n")
+		status, err := ip.downloadwith()
+		if !status || err != nil {
+			return status, err
+		}
+	} else if token.Equals("downloadsum", types.TokenTypeKeyword) {
+		ip.runtimev("downloadsum command.\n")
+		status, err := ip.downloadsum()
+		if !status || err != nil {
+			return status, err
+		}
+	} else if token.Equals("hash", types.TokenTypeKeyword) {
+		ip.runtimev("hash command.\n")
+		status, err := ip.hash()
+		if !status || err != nil {
+			return status, err
+		}
 	} else if token.Equals("readfile", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 1 {
-			return ip.runtimeverr("failed to run step. readfile command failed. stack is empty.\n", ip.stack.Len())
+			return ip.runtimeverr("failed to run step. readfile command failed. stack is empty.\n")
 		}
 
 		ip.runtimev("readfile command.\n")
@@ -1655,7 +1699,7 @@ func (ip *Interpreter) Step() (bool, error) {
 		data, err := tools.ToolReadfile(pSrc)
 		if err != nil {
 			ip.runtimev("failed to use readfile too: %v\n", err)
-			err = ip.ipush(0)
+			err = ip.bpush(false)
 			if err != nil {
 				return false, fmt.Errorf("failed to run step. readfile command failed. failure pushing value: %v", err)
 			}
@@ -1665,7 +1709,7 @@ func (ip *Interpreter) Step() (bool, error) {
 				return false, fmt.Errorf("failed to run step. readfile command failed. failure pushing value: %v", err)
 			}
 
-			err = ip.ipush(1)
+			err = ip.bpush(true)
 			if err != nil {
 				return false, fmt.Errorf("failed to run step. readfile command failed. failure pushing value: %v", err)
 			}
@@ -1696,14 +1740,14 @@ func (ip *Interpreter) Step() (bool, error) {
 			return ip.runtimeverr("failed to run step. move command failed. failed to get source path.\n")
 		}
 
-		var result int = 1
+		var result bool = true
 		err = tools.ToolMoveFile(pSrc, pDst)
 		if err != nil {
 			ip.runtimev("failed to use move tool: %v\n", err)
-			result = 0
+			result = false
 		}
 
-		err = ip.ipush(result)
+		err = ip.bpush(result)
 		if err != nil {
 			return false, fmt.Errorf("failed to run step. move command failed. failure pushing value: %v", err)
 		}
@@ -1738,35 +1782,35 @@ func (ip *Interpreter) Step() (bool, error) {
 			ip.runtimev("failed to use copy tool: %v\n", err)
 			if occupied {
 				// File already exists: push true false
-				err = ip.ipush(1)
+				err = ip.bpush(true)
 				if err != nil {
 					return false, fmt.Errorf("failed to run step. copy command failed. failure pushing value: %v", err)
 				}
-				err = ip.ipush(0)
+				err = ip.bpush(false)
 				if err != nil {
 					return false, fmt.Errorf("failed to run step. copy command failed. failure pushing value: %v", err)
 				}
 			} else {
 				// Other failure: push false false
-				err = ip.ipush(0)
+				err = ip.bpush(false)
 				if err != nil {
 					return false, fmt.Errorf("failed to run step. copy command failed. failure pushing value: %v", err)
 				}
-				err = ip.ipush(0)
+				err = ip.bpush(false)
 				if err != nil {
 					return false, fmt.Errorf("failed to run step. copy command failed. failure pushing value: %v", err)
 				}
 			}
 		} else {
 			// Success: push true
-			err = ip.ipush(1)
+			err = ip.bpush(true)
 			if err != nil {
 				return false, fmt.Errorf("failed to run step. copy command failed. failure pushing value: %v", err)
 			}
 		}
 	} else if token.Equals("exist", types.TokenTypeKeyword) {
 		if ip.stack.Len() == 0 {
-			return ip.runtimeverr("failed to run step. exist command failed. stack is empty.\n", ip.stack.Len())
+			return ip.runtimeverr("failed to run step. exist command failed. stack is empty.\n")
 		}
 
 		ip.runtimev("exist command.\n")
@@ -1783,19 +1827,19 @@ func (ip *Interpreter) Step() (bool, error) {
 		err = tools.ToolExistFile(pRes)
 		if err != nil {
 			ip.runtimev("failed to use exist tool: %v\n", err)
-			err = ip.ipush(0)
+			err = ip.bpush(false)
 			if err != nil {
 				return false, fmt.Errorf("failed to run step. exist command failed. failure pushing value: %v", err)
 			}
 		} else {
-			err = ip.ipush(1)
+			err = ip.bpush(true)
 			if err != nil {
 				return false, fmt.Errorf("failed to run step. exist command failed. failure pushing value: %v", err)
 			}
 		}
 	} else if token.Equals("touch", types.TokenTypeKeyword) {
 		if ip.stack.Len() < 1 {
-			return ip.runtimeverr("failed to run step. touch command failed. stack is empty.\n", ip.stack.Len())
+			return ip.runtimeverr("failed to run step. touch command failed. stack is empty.\n")
 		}
 
 		ip.runtimev("touch command.\n")
@@ -1809,20 +1853,68 @@ func (ip *Interpreter) Step() (bool, error) {
 			return ip.runtimeverr("failed to run step. touch command failed. failed to get path.\n")
 		}
 
-		var result int = 1
+		var result bool = true
 		err = tools.ToolTouchFile(pPath)
 		if err != nil {
 			ip.runtimev("failed to use touch tool: %v\n", err)
-			result = 0
+			result = false
 		}
 
-		err = ip.ipush(result)
+		err = ip.bpush(result)
 		if err != nil {
 			return false, fmt.Errorf("failed to run step. touch command failed. failure pushing value: %v", err)
 		}
+	} else if token.Equals("exec", types.TokenTypeKeyword) {
+		ip.runtimev("exec command.\n")
+		status, err := ip.exec()
+		if !status || err != nil {
+			return status, err
+		}
+	} else if token.Equals("execwith", types.TokenTypeKeyword) {
+		ip.runtimev("execwith command.\n")
+		status, err := ip.execwith()
+		if !status || err != nil {
+			return status, err
+		}
+	} else if token.Equals("http", types.TokenTypeKeyword) {
+		ip.runtimev("http command.\n")
+		status, err := ip.http()
+		if !status || err != nil {
+			return status, err
+		}
+	} else if token.Equals("getenv", types.TokenTypeKeyword) {
+		ip.runtimev("getenv command.\n")
+		status, err := ip.getenv()
+		if !status || err != nil {
+			return status, err
+		}
+	} else if token.Equals("setenv", types.TokenTypeKeyword) {
+		ip.runtimev("setenv command.\n")
+		status, err := ip.setenv()
+		if !status || err != nil {
+			return status, err
+		}
+	} else if token.Equals("unsetenv", types.TokenTypeKeyword) {
+		ip.runtimev("unsetenv command.\n")
+		status, err := ip.unsetenv()
+		if !status || err != nil {
+			return status, err
+		}
+	} else if token.Equals("env", types.TokenTypeKeyword) {
+		ip.runtimev("env command.\n")
+		status, err := ip.env()
+		if !status || err != nil {
+			return status, err
+		}
+	} else if token.Equals("loadenv", types.TokenTypeKeyword) {
+		ip.runtimev("loadenv command.\n")
+		status, err := ip.loadenv()
+		if !status || err != nil {
+			return status, err
+		}
 	} else if token.Equals("mkdir", types.TokenTypeKeyword) {
 		if ip.stack.Len() == 0 {
-			return ip.runtimeverr("failed to run step. mkdir command failed. stack is empty.\n", ip.stack.Len())
+			return ip.runtimeverr("failed to run step. mkdir command failed. stack is empty.\n")
 		}
 
 		ip.runtimev("mkdir command.\n")
@@ -1836,20 +1928,20 @@ func (ip *Interpreter) Step() (bool, error) {
 			return ip.runtimeverr("failed to run step. mkdir command failed. failed to get path.\n")
 		}
 
-		var result int = 1
+		var result bool = true
 		err = tools.ToolMakeDirectory(pPath)
 		if err != nil {
 			ip.runtimev("failed to use mkdir tool: %v\n", err)
-			result = 0
+			result = false
 		}
 
-		err = ip.ipush(result)
+		err = ip.bpush(result)
 		if err != nil {
 			return false, fmt.Errorf("failed to run step. mkdir command failed. failure pushing value: %v", err)
 		}
 	} else if token.Equals("rm", types.TokenTypeKeyword) {
 		if ip.stack.Len() == 0 {
-			return ip.runtimeverr("failed to run step. rm command failed. stack is empty.\n", ip.stack.Len())
+			return ip.runtimeverr("failed to run step. rm command failed. stack is empty.\n")
 		}
 
 		ip.runtimev("rm command.\n")
@@ -1866,12 +1958,12 @@ func (ip *Interpreter) Step() (bool, error) {
 		err = tools.ToolRemoveFile(pRes)
 		if err != nil {
 			ip.runtimev("failed to use rm tool: %v\n", err)
-			err = ip.ipush(0)
+			err = ip.bpush(false)
 			if err != nil {
 				return false, fmt.Errorf("failed to run step. rm command failed. failure pushing value: %v", err)
 			}
 		} else {
-			err = ip.ipush(1)
+			err = ip.bpush(true)
 			if err != nil {
 				return false, fmt.Errorf("failed to run step. rm command failed. failure pushing value: %v", err)
 			}
@@ -1927,17 +2019,14 @@ func (ip *Interpreter) Step() (bool, error) {
 			return ip.runtimeverr("failed to run step. lsf command failed. failed to get dir path.\n")
 		}
 
-		var result int = 0
-		count, err := tools.ToolLsf(pDir)
+		names, err := tools.ToolLsf(pDir)
 		if err != nil {
-			ip.runtimev("failed to use lsf tool: %v\n", err)
-		} else {
-			result = int(count)
+			return ip.toolerr("failed to run step. lsf command failed. %v\n", err)
 		}
 
-		err = ip.ipush(result)
+		err = ip.push(StackList(stringList(names)))
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. lsf command failed. failure pushing count: %v", err)
+			return false, fmt.Errorf("failed to run step. lsf command failed. failure pushing list: %v", err)
 		}
 	} else if token.Equals("getf", types.TokenTypeKeyword) {
 		ip.runtimev("getf command.\n")
@@ -1985,17 +2074,14 @@ func (ip *Interpreter) Step() (bool, error) {
 			return ip.runtimeverr("failed to run step. lsd command failed. failed to get dir path.\n")
 		}
 
-		var result int = 0
-		count, err := tools.ToolLsd(pDir)
+		names, err := tools.ToolLsd(pDir)
 		if err != nil {
-			ip.runtimev("failed to use lsd tool: %v\n", err)
-		} else {
-			result = int(count)
+			return ip.toolerr("failed to run step. lsd command failed. %v\n", err)
 		}
 
-		err = ip.ipush(result)
+		err = ip.push(StackList(stringList(names)))
 		if err != nil {
-			return false, fmt.Errorf("failed to run step. lsd command failed. failure pushing count: %v", err)
+			return false, fmt.Errorf("failed to run step. lsd command failed. failure pushing list: %v", err)
 		}
 	} else if token.Equals("getd", types.TokenTypeKeyword) {
 		ip.runtimev("getd command.\n")
@@ -2021,7 +2107,7 @@ func (ip *Interpreter) Step() (bool, error) {
 
 		result, err := tools.ToolGetd(iIdx, pDir)
 		if err != nil {
-			return ip.runtimeverr("failed to run step. getd command failed. %v\n", err)
+			return ip.toolerr("failed to run step. getd command failed. %v\n", err)
 		}
 
 		err = ip.spush(result)
@@ -2070,16 +2156,7 @@ func (ip *Interpreter) Step() (bool, error) {
 			return ip.runtimeverr("failed to run step. tostring command failed. failed to get value: %v\n", err)
 		}
 
-		var result string
-		if i, ok := v.Int(); ok {
-			result = tools.ToolToStringInt(i)
-		} else if s, ok := v.String(); ok {
-			result = tools.ToolToStringString(s)
-		} else if p, ok := v.Path(); ok {
-			result = tools.ToolToStringPath(p)
-		} else {
-			result = ""
-		}
+		result := toString(v)
 
 		err = ip.spush(result)
 		if err != nil {
@@ -2141,15 +2218,14 @@ func (ip *Interpreter) Step() (bool, error) {
 		}
 	} else if token.Equals("exit", types.TokenTypeKeyword) {
 		ip.runtimev("exit command. exiting...\n")
-		ip.ip = ip.eop
-		return true, nil
+		return false, &ExitRequest{Pos: token.Pos}
 	} else {
 		if IsVerboseRuntime() {
 			tokenLog := token.Format()
 			ip.runtimev("invalid token [%s] at ip %d\n", tokenLog, ip.ip)
 		}
 
-		return false, fmt.Errorf("failed to run step. unknown operator at ip %d\n", ip.ip)
+		return ip.runtimeverr("failed to run step. unknown operator '%s'.", token.Value)
 	}
 
 	ip.ip++
@@ -2157,41 +2233,16 @@ func (ip *Interpreter) Step() (bool, error) {
 	return true, nil
 }
 
-func (ip *Interpreter) load(name string) (StackValue, error) {
-	var value StackValue
-	value, ok := ip.memory[name]
+func (ip *Interpreter) ret() (bool, error) {
+	frame, ok := ip.calls.Pop()
 	if !ok {
-		return value, fmt.Errorf("failed to load(%s). value not found.", name)
-	}
-
-	left, ok := value.String()
-	if ok {
-		ip.runtimev("loaded \"%s\"\n", left)
-		return value, nil
-	}
-
-	right, ok := value.Int()
-	if ok {
-		ip.runtimev("loaded %d\n", right)
-		return value, nil
-	}
-
-	return value, fmt.Errorf("failed to load(%s). value found, but unknown type.", name)
-}
-
-func (ip *Interpreter) store(name string, value StackValue) error {
-	left, okLeft := value.String()
-	right, okRight := value.Int()
-	if okLeft {
-		ip.runtimev("stored \"%s\"\n", left)
-	} else if okRight {
-		ip.runtimev("stored %d\n", right)
-	} else {
-		return fmt.Errorf("failed to store(%s). value given, but unknown type.", name)
+		return ip.runtimeverr("failed to run step. return failed. call stack is empty.\n")
 	}
 
-	ip.memory[name] = value
-	return nil
+	ip.runtimev("returning from proc '%s'.\n", frame.Proc)
+	ip.iters = ip.iters[:frame.Iters]
+	ip.ip = frame.Return
+	return true, nil
 }
 
 func (ip *Interpreter) pop() (StackValue, error) {
@@ -2201,6 +2252,7 @@ func (ip *Interpreter) pop() (StackValue, error) {
 		return value, fmt.Errorf("failed to ipop. stack is empty.")
 	}
 
+	ip.popped = append(ip.popped, value)
 	ip.runtimev("new stack size %d\n", ip.stack.Len())
 
 	return value, nil
@@ -2242,6 +2294,14 @@ func (ip *Interpreter) spush(item string) error {
 	return ip.push(StackString(item))
 }
 
+func (ip *Interpreter) fpush(item float64) error {
+	return ip.push(StackFloat(item))
+}
+
+func (ip *Interpreter) bpush(item bool) error {
+	return ip.push(StackBool(item))
+}
+
 func (ip *Interpreter) ppush(item string) error {
 	return ip.push(StackPath(item))
 }
@@ -0,0 +1,120 @@
+package interpreter
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ktnuity/wet/internal/tools"
+)
+
+// Iteration state of a running each loop. Map loops carry the key of every item.
+type iterator struct {
+	items		[]StackValue
+	keys		[]string
+	idx			int
+}
+
+func stringList(items []string) []StackValue {
+	result := make([]StackValue, len(items))
+	for idx, item := range items {
+		result[idx] = StackString(item)
+	}
+
+	return result
+}
+
+func formatList(items []StackValue) string {
+	parts := make([]string, len(items))
+	for idx, item := range items {
+		parts[idx] = item.Format()
+	}
+
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// Entries are rendered in key order, so equal maps always print the same.
+func formatMap(entries map[string]StackValue) string {
+	keys := sortedKeys(entries)
+	parts := make([]string, len(keys))
+	for idx, key := range keys {
+		parts[idx] = fmt.Sprintf("%q %s", key, entries[key].Format())
+	}
+
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+func sortedKeys(entries map[string]StackValue) []string {
+	return slices.Sorted(maps.Keys(entries))
+}
+
+// Renders a value the way tostring does.
+func toString(v StackValue) string {
+	if i, ok := v.Int(); ok {
+		return tools.ToolToStringInt(i)
+	} else if f, ok := v.Float(); ok {
+		return tools.ToolToStringFloat(f)
+	} else if b, ok := v.Bool(); ok {
+		return tools.ToolToStringBool(b)
+	} else if s, ok := v.String(); ok {
+		return tools.ToolToStringString(s)
+	} else if p, ok := v.Path(); ok {
+		return tools.ToolToStringPath(p)
+	} else if l, ok := v.List(); ok {
+		return formatList(l)
+	} else if m, ok := v.Map(); ok {
+		return formatMap(m)
+	}
+
+	return ""
+}
+
+// Advances the innermost each loop. Jumps back into the body at start while items remain.
+func (ip *Interpreter) next(start int) (bool, error) {
+	ip.popScope()
+
+	if ip.iters.Len() == 0 {
+		return ip.runtimeverr("failed to run step. each loop failed. no loop is running.\n")
+	}
+
+	iter := &ip.iters[ip.iters.Len() - 1]
+	iter.idx++
+
+	if iter.idx >= len(iter.items) {
+		ip.runtimev("each (leave loop).\n")
+		ip.iters.Pop()
+		ip.ip++
+		return true, nil
+	}
+
+	ip.pushScope()
+
+	err := ip.pushItem(iter)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. each loop failed. failure pushing value: %v", err)
+	}
+
+	ip.ip = start + 1
+	return true, nil
+}
+
+// Pushes the current item of iter. Map loops push the key below the value.
+func (ip *Interpreter) pushItem(iter *iterator) error {
+	if iter.keys != nil {
+		err := ip.spush(iter.keys[iter.idx])
+		if err != nil {
+			return err
+		}
+		ip.runtimev("pushed %q\n", iter.keys[iter.idx])
+	}
+
+	item := iter.items[iter.idx]
+	err := ip.push(item)
+	if err != nil {
+		return err
+	}
+	ip.runtimev("pushed %s\n", item.Format())
+
+	return nil
+}
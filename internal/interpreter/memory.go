@@ -0,0 +1,158 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ktnuity/wet/internal/types"
+)
+
+type Scope = map[string]StackValue
+
+// Bodies of macros with parameters are wrapped in these during expansion. The tokenizer never
+// classifies them as keywords, so scripts cannot produce them directly.
+const (
+	scopeEnter = "@enter"
+	scopeLeave = "@leave"
+)
+
+// Follows scopeEnter in macros with parameters. Pops one value per parameter into the macro scope.
+const macroBind = "@bind"
+
+// Returns the scope chain of the running proc, or the top-level chain outside of procs.
+// The first scope of the top-level chain is the global scope.
+func (ip *Interpreter) chain() *[]Scope {
+	if ip.calls.Len() > 0 {
+		return &ip.calls[ip.calls.Len() - 1].Scopes
+	}
+
+	return &ip.scopes
+}
+
+// Scopes are allocated lazily, as most loop iterations and macro bodies never store anything.
+func (ip *Interpreter) pushScope() {
+	chain := ip.chain()
+	*chain = append(*chain, nil)
+}
+
+func (ip *Interpreter) popScope() {
+	chain := ip.chain()
+	if len(*chain) > 1 {
+		*chain = (*chain)[:len(*chain) - 1]
+	}
+}
+
+// Splits a memory name into its lookup name and whether it targets the global scope.
+func parseName(name string) (string, bool, error) {
+	global := strings.HasPrefix(name, ".")
+	if global {
+		name = name[1:]
+	}
+
+	if name == "" {
+		return "", false, fmt.Errorf("memory name is empty.")
+	}
+
+	return name, global, nil
+}
+
+// Resolves name through the current scope chain, innermost first.
+// Names prefixed with '.' resolve in the global scope.
+func (ip *Interpreter) load(name string) (StackValue, bool, error) {
+	var value StackValue
+
+	key, global, err := parseName(name)
+	if err != nil {
+		return value, false, fmt.Errorf("failed to load(%s). %v", name, err)
+	}
+
+	if global {
+		value, ok := ip.memory[key]
+		if ok {
+			ip.runtimev("loaded global %s\n", value.Format())
+		} else {
+			ip.runtimev("global '%s' not found\n", key)
+		}
+
+		return value, ok, nil
+	}
+
+	chain := *ip.chain()
+	for idx := len(chain) - 1; idx >= 0; idx-- {
+		if value, ok := chain[idx][key]; ok {
+			ip.runtimev("loaded %s\n", value.Format())
+			return value, true, nil
+		}
+	}
+
+	ip.runtimev("local '%s' not found\n", key)
+	return value, false, nil
+}
+
+// Updates name in the closest scope that already holds it, or defines it in the innermost scope.
+// Names prefixed with '.' are always stored in the global scope.
+func (ip *Interpreter) store(name string, value StackValue) error {
+	if !value.IsAny() {
+		return fmt.Errorf("failed to store(%s). value given, but unknown type.", name)
+	}
+
+	key, global, err := parseName(name)
+	if err != nil {
+		return fmt.Errorf("failed to store(%s). %v", name, err)
+	}
+
+	if global {
+		ip.memory[key] = value
+		ip.runtimev("stored global %s\n", value.Format())
+		return nil
+	}
+
+	chain := *ip.chain()
+	for idx := len(chain) - 1; idx >= 0; idx-- {
+		if _, ok := chain[idx][key]; ok {
+			chain[idx][key] = value
+			ip.runtimev("stored %s\n", value.Format())
+			return nil
+		}
+	}
+
+	last := len(chain) - 1
+	if chain[last] == nil {
+		chain[last] = make(Scope)
+	}
+
+	chain[last][key] = value
+	ip.runtimev("stored %s\n", value.Format())
+	return nil
+}
+
+// Defines name in the innermost scope, shadowing any outer value of the same name.
+func (ip *Interpreter) define(name string, value StackValue) {
+	chain := *ip.chain()
+	last := len(chain) - 1
+	if chain[last] == nil {
+		chain[last] = make(Scope)
+	}
+
+	chain[last][name] = value
+	ip.runtimev("defined %s\n", value.Format())
+}
+
+// Pops one value per macro parameter, last parameter first, and defines each in the macro scope.
+func (ip *Interpreter) bind(expansion *types.Expansion) (bool, error) {
+	params := expansion.Params
+	if ip.stack.Len() < len(params) {
+		return ip.runtimeverr("failed to expand macro '%s'. expected %d argument(s) (%s), but the stack has %d.\n", expansion.Macro, len(params), strings.Join(params, " "), ip.stack.Len())
+	}
+
+	for idx := len(params) - 1; idx >= 0; idx-- {
+		value, err := ip.pop()
+		if err != nil {
+			return ip.runtimeverr("failed to expand macro '%s'. failed to get argument '%s': %v\n", expansion.Macro, params[idx], err)
+		}
+
+		ip.define(params[idx], value)
+	}
+
+	return true, nil
+}
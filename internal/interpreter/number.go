@@ -0,0 +1,292 @@
+package interpreter
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Operands of a binary numeric operator. When either side is a float, both are promoted.
+type numberPair struct {
+	i1, i2		int
+	f1, f2		float64
+	float		bool
+}
+
+func numbers(v1, v2 StackValue) (numberPair, bool) {
+	if !v1.IsNumber() || !v2.IsNumber() {
+		return numberPair{}, false
+	}
+
+	n1, ok1 := v1.Int()
+	n2, ok2 := v2.Int()
+	if ok1 && ok2 {
+		return numberPair{i1: n1, i2: n2}, true
+	}
+
+	f1, _ := v1.Number()
+	f2, _ := v2.Number()
+	return numberPair{f1: f1, f2: f2, float: true}, true
+}
+
+// Orders a against b. Strings compare with strings, and numbers with numbers.
+func compareValues(a, b StackValue) (int, bool) {
+	if sa, ok := a.String(); ok {
+		if sb, ok := b.String(); ok {
+			return strings.Compare(sa, sb), true
+		}
+
+		return 0, false
+	}
+
+	np, ok := numbers(b, a)
+	if !ok {
+		return 0, false
+	}
+
+	if np.float {
+		return cmp.Compare(np.f2, np.f1), true
+	}
+
+	return cmp.Compare(np.i2, np.i1), true
+}
+
+// Values of different kinds are never equal, except ints and floats which compare by value,
+// and bools which compare with numbers as 1 and 0. Lists and maps are equal when all items are.
+func equalValues(a, b StackValue) bool {
+	if order, ok := compareValues(boolAsInt(a), boolAsInt(b)); ok {
+		return order == 0
+	}
+
+	if ba, ok := a.Bool(); ok {
+		if bb, ok := b.Bool(); ok {
+			return ba == bb
+		}
+	}
+
+	if pa, ok := a.Path(); ok {
+		if pb, ok := b.Path(); ok {
+			return pa == pb
+		}
+	}
+
+	if la, ok := a.List(); ok {
+		if lb, ok := b.List(); ok {
+			return slices.EqualFunc(la, lb, equalValues)
+		}
+	}
+
+	if ma, ok := a.Map(); ok {
+		if mb, ok := b.Map(); ok {
+			return maps.EqualFunc(ma, mb, equalValues)
+		}
+	}
+
+	return false
+}
+
+// Bools only coerce to numbers when compared against one.
+func boolAsInt(v StackValue) StackValue {
+	if b, ok := v.Bool(); ok {
+		if b {
+			return StackInt(1)
+		}
+
+		return StackInt(0)
+	}
+
+	return v
+}
+
+func divInt(a, b int) int {
+	if b == 0 {
+		return 0
+	}
+
+	return a / b
+}
+
+func divFloat(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+
+	return a / b
+}
+
+func modInt(a, b int) int {
+	if b == 0 {
+		return 0
+	}
+
+	return a % b
+}
+
+func modFloat(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+
+	return math.Mod(a, b)
+}
+
+// Pops two numbers and pushes the second popped value combined with the first.
+// The result stays an int unless either operand is a float.
+func (ip *Interpreter) arithmetic(name, verb string, opInt func(a, b int) int, opFloat func(a, b float64) float64) (bool, error) {
+	if ip.stack.Len() < 2 {
+		return ip.runtimeverr("failed to run step. %s operator failed. stack size is %d. 2 is required.\n", name, ip.stack.Len())
+	}
+
+	v1, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. %s operator failed. failed to get first value: %v\n", name, err)
+	}
+	ip.runtimev("popped %s\n", v1.Format())
+
+	v2, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. %s operator failed. failed to get second value: %v\n", name, err)
+	}
+	ip.runtimev("popped %s\n", v2.Format())
+
+	np, ok := numbers(v1, v2)
+	if !ok {
+		return ip.runtimeverr("failed to run step. %s operator failed. cannot %s %s and %s.\n", name, verb, v2.Kind(), v1.Kind())
+	}
+
+	var result StackValue
+	if np.float {
+		result = StackFloat(opFloat(np.f2, np.f1))
+	} else {
+		result = StackInt(opInt(np.i2, np.i1))
+	}
+
+	err = ip.push(result)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. %s operator failed. failure pushing value: %v", name, err)
+	}
+	ip.runtimev("pushed %s\n", result.Format())
+
+	return true, nil
+}
+
+// Pops a number and pushes it moved by delta.
+func (ip *Interpreter) increment(name string, delta int) (bool, error) {
+	if ip.stack.Len() < 1 {
+		return ip.runtimeverr("failed to run step. %s operator failed. stack is empty.\n", name)
+	}
+
+	v1, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. %s operator failed. failed to get value: %v\n", name, err)
+	}
+	ip.runtimev("popped %s\n", v1.Format())
+
+	var result StackValue
+	if n1, ok := v1.Int(); ok {
+		result = StackInt(n1 + delta)
+	} else if f1, ok := v1.Float(); ok {
+		result = StackFloat(f1 + float64(delta))
+	} else {
+		return ip.runtimeverr("failed to run step. %s operator failed. cannot step %s.\n", name, v1.Kind())
+	}
+
+	err = ip.push(result)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. %s operator failed. failure pushing value: %v", name, err)
+	}
+	ip.runtimev("pushed %s\n", result.Format())
+
+	return true, nil
+}
+
+// Pops two values and pushes true if the second popped value relates to the first as accepted, false otherwise.
+// Only strings and numbers can be ordered.
+func (ip *Interpreter) comparison(name string, accept func(order int) bool) (bool, error) {
+	if ip.stack.Len() < 2 {
+		return ip.runtimeverr("failed to run step. %s operator failed. stack size is %d. 2 is reqired.\n", name, ip.stack.Len())
+	}
+
+	v1, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. %s operator failed. failed to get first value: %v\n", name, err)
+	}
+	ip.runtimev("popped %s\n", v1.Format())
+
+	v2, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. %s operator failed. failed to get second value: %v\n", name, err)
+	}
+	ip.runtimev("popped %s\n", v2.Format())
+
+	order, ok := compareValues(v2, v1)
+	if !ok {
+		return ip.runtimeverr("failed to run step. %s operator failed. cannot compare %s and %s.\n", name, v2.Kind(), v1.Kind())
+	}
+
+	result := accept(order)
+
+	err = ip.bpush(result)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. %s operator failed. failure pushing value: %v", name, err)
+	}
+	ip.runtimev("pushed %t\n", result)
+
+	return true, nil
+}
+
+// Pops two values and pushes true if their equality matches expect, false otherwise.
+// Values of any kind can be checked for equality.
+func (ip *Interpreter) equality(name string, expect bool) (bool, error) {
+	if ip.stack.Len() < 2 {
+		return ip.runtimeverr("failed to run step. %s operator failed. stack size is %d. 2 is reqired.\n", name, ip.stack.Len())
+	}
+
+	v1, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. %s operator failed. failed to get first value: %v\n", name, err)
+	}
+	ip.runtimev("popped %s\n", v1.Format())
+
+	v2, err := ip.pop()
+	if err != nil {
+		return ip.runtimeverr("failed to run step. %s operator failed. failed to get second value: %v\n", name, err)
+	}
+	ip.runtimev("popped %s\n", v2.Format())
+
+	result := equalValues(v2, v1) == expect
+
+	err = ip.bpush(result)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. %s operator failed. failure pushing value: %v", name, err)
+	}
+	ip.runtimev("pushed %t\n", result)
+
+	return true, nil
+}
+
+// Pushes a converted value followed by whether the conversion succeeded.
+// A failed conversion pushes 0 in place of the value.
+func (ip *Interpreter) pushConversion(value StackValue, ok bool) (bool, error) {
+	name := operatorName(ip.program[ip.ip].Token)
+	if !ok {
+		value = StackInt(0)
+	}
+
+	err := ip.push(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. %s operator failed. failure pushing value: %v", name, err)
+	}
+	ip.runtimev("pushed %s\n", value.Format())
+
+	err = ip.bpush(ok)
+	if err != nil {
+		return false, fmt.Errorf("failed to run step. %s operator failed. failure pushing value: %v", name, err)
+	}
+	ip.runtimev("pushed %t\n", ok)
+
+	return true, nil
+}
@@ -0,0 +1,178 @@
+package source
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/ktnuity/wet/internal/types"
+)
+
+// An open '@if' block. Lines are kept only while every enclosing block is active.
+type condition struct {
+	line		types.SourceLine
+	active		bool
+	parent		bool
+	inElse		bool
+}
+
+// Conditional state of a single file. Blocks must be closed in the file that opened them.
+type conditions struct {
+	stack		[]condition
+}
+
+func (c *conditions) active() bool {
+	if len(c.stack) == 0 {
+		return true
+	}
+
+	return c.stack[len(c.stack) - 1].active
+}
+
+// Directives are lines starting with '@', ignoring indentation. process skips lines that continue a
+// string or block comment, so those are never directives.
+// Returns the directive name and the rest of the line split into words.
+func parseDirective(line types.SourceLine) (string, []string, bool) {
+	text := strings.TrimSpace(line.Text)
+	if !strings.HasPrefix(text, "@") {
+		return "", nil, false
+	}
+
+	fields := strings.Fields(text)
+	return fields[0], fields[1:], true
+}
+
+// Applies a conditional directive. Returns false for directives that are not conditionals.
+func (c *conditions) apply(line types.SourceLine, name string, params []string, defines map[string]string) (bool, error) {
+	switch name {
+	case "@if":
+		parent := c.active()
+		active := false
+		if parent {
+			result, err := evalCondition(params, defines)
+			if err != nil {
+				return true, lineError(line, "failed to evaluate @if: %v", err)
+			}
+
+			active = result
+		}
+
+		c.stack = append(c.stack, condition{
+			line: line,
+			active: active,
+			parent: parent,
+		})
+	case "@else":
+		if len(params) > 0 {
+			return true, lineError(line, "@else takes no arguments.")
+		}
+
+		if len(c.stack) == 0 {
+			return true, lineError(line, "@else reached without @if.")
+		}
+
+		top := &c.stack[len(c.stack) - 1]
+		if top.inElse {
+			return true, lineError(line, "@else reached twice for the same @if.")
+		}
+
+		top.inElse = true
+		top.active = top.parent && !top.active
+	case "@endif":
+		if len(params) > 0 {
+			return true, lineError(line, "@endif takes no arguments.")
+		}
+
+		if len(c.stack) == 0 {
+			return true, lineError(line, "@endif reached without @if.")
+		}
+
+		c.stack = c.stack[:len(c.stack) - 1]
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Reports the innermost block left open at the end of a file.
+func (c *conditions) close() error {
+	if len(c.stack) == 0 {
+		return nil
+	}
+
+	return lineError(c.stack[len(c.stack) - 1].line, "@if is never closed. expected @endif.")
+}
+
+// Evaluates the arguments of an '@if'. Every condition may be negated with a leading 'not'.
+//   - os <name>: the current os is <name>. 'macos' is accepted for darwin.
+//   - env <NAME> [value]: the environment variable is set and non-empty, or equal to value.
+//   - defined <NAME> [value]: NAME was defined, or defined as value.
+func evalCondition(params []string, defines map[string]string) (bool, error) {
+	negate := len(params) > 0 && params[0] == "not"
+	if negate {
+		params = params[1:]
+	}
+
+	if len(params) == 0 {
+		return false, fmt.Errorf("missing condition. expected 'os', 'env' or 'defined'.")
+	}
+
+	var result bool
+	switch params[0] {
+	case "os":
+		if len(params) != 2 {
+			return false, fmt.Errorf("'os' expects 1 argument, but got %d.", len(params) - 1)
+		}
+
+		name := params[1]
+		if name == "macos" {
+			name = "darwin"
+		}
+
+		result = runtime.GOOS == name
+	case "env":
+		if len(params) != 2 && len(params) != 3 {
+			return false, fmt.Errorf("'env' expects 1 or 2 arguments, but got %d.", len(params) - 1)
+		}
+
+		value, ok := os.LookupEnv(params[1])
+		if len(params) == 3 {
+			result = ok && value == params[2]
+		} else {
+			result = ok && value != ""
+		}
+	case "defined":
+		if len(params) != 2 && len(params) != 3 {
+			return false, fmt.Errorf("'defined' expects 1 or 2 arguments, but got %d.", len(params) - 1)
+		}
+
+		value, ok := defines[params[1]]
+		if len(params) == 3 {
+			result = ok && value == params[2]
+		} else {
+			result = ok
+		}
+	default:
+		return false, fmt.Errorf("unknown condition '%s'. expected 'os', 'env' or 'defined'.", params[0])
+	}
+
+	return result != negate, nil
+}
+
+// '@define NAME [value]' defines NAME for later '@if defined' checks. Names defined on the
+// command line take precedence, so a script can declare defaults that -D overrides.
+func define(line types.SourceLine, params []string, defines map[string]string, fixed map[string]string) error {
+	if len(params) == 0 {
+		return lineError(line, "@define expects a name.")
+	}
+
+	name := params[0]
+	if _, ok := fixed[name]; ok {
+		return nil
+	}
+
+	defines[name] = strings.Join(params[1:], " ")
+	return nil
+}
@@ -0,0 +1,276 @@
+package source
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ktnuity/wet/internal/stdlib"
+	"github.com/ktnuity/wet/internal/tools"
+	"github.com/ktnuity/wet/internal/types"
+	"github.com/ktnuity/wet/internal/util"
+)
+
+// Include depth used when none is given on the command line. The entry script is depth 1.
+const DefaultIncludeDepth = 16
+
+// Imports starting with this prefix name a module embedded in the std.
+const stdPrefix = "std/"
+
+// Search paths listed in this environment variable are tried after the -I paths.
+const includePathEnv = "WET_PATH"
+
+// A file on the include chain. key is the resolved absolute path, name is what errors show.
+type includedFile struct {
+	key		string
+	name	string
+}
+
+// State shared by every file loaded for a single run.
+type loader struct {
+	args			*types.WetArgs
+	defines			map[string]string
+	maxDepth		int
+	searchPaths		[]string
+	included		map[string]bool
+	chain			[]includedFile
+}
+
+func newLoader(args *types.WetArgs) *loader {
+	defines := maps.Clone(args.Defines)
+	if defines == nil {
+		defines = make(map[string]string)
+	}
+
+	maxDepth := args.IncludeDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultIncludeDepth
+	}
+
+	return &loader{
+		args: args,
+		defines: defines,
+		maxDepth: maxDepth,
+		included: make(map[string]bool),
+	}
+}
+
+// Resolves the -I paths followed by the paths in WET_PATH to absolute directories.
+func includePaths(paths []string) ([]string, error) {
+	paths = slices.Clone(paths)
+	if env := os.Getenv(includePathEnv); env != "" {
+		paths = append(paths, filepath.SplitList(env)...)
+	}
+
+	result := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve include path '%s': %v", path, err)
+		}
+
+		result = append(result, abs)
+	}
+
+	return result, nil
+}
+
+// Marks path as loaded and places it on the include chain.
+func (ld *loader) enter(path string) error {
+	key, err := fileKey(path)
+	if err != nil {
+		return err
+	}
+
+	ld.push(key, displayName(path))
+	return nil
+}
+
+func (ld *loader) push(key, name string) {
+	ld.included[key] = true
+	ld.chain = append(ld.chain, includedFile{key: key, name: name})
+}
+
+func (ld *loader) leave() {
+	ld.chain = ld.chain[:len(ld.chain) - 1]
+}
+
+// Loads and processes the file named by an '@include' on line. Every file is included at most once,
+// so including a file again is a no-op. Including a file that is still being loaded is a cycle.
+func (ld *loader) include(line types.SourceLine, fileName string, depth int) ([]types.SourceLine, error) {
+	if !strings.HasSuffix(fileName, ".wet") {
+		return nil, lineError(line, "failed to include source. file '%s' has invalid suffix.", fileName)
+	}
+
+	path, err := ld.resolve(line.File, fileName)
+	if err != nil {
+		return nil, lineError(line, "failed to include source. %v", err)
+	}
+
+	key, err := fileKey(path)
+	if err != nil {
+		return nil, lineError(line, "failed to include source. %v", err)
+	}
+
+	if idx := slices.IndexFunc(ld.chain, func(file includedFile) bool { return file.key == key }); idx != -1 {
+		return nil, lineError(line, "failed to include source. include cycle: %s.", ld.trace(idx, displayName(path)))
+	}
+
+	if ld.included[key] {
+		return nil, nil
+	}
+
+	if depth >= ld.maxDepth {
+		return nil, lineError(line, "failed to include source. include depth limit of %d reached: %s.", ld.maxDepth, ld.trace(0, displayName(path)))
+	}
+
+	content, err := loadFile(path)
+	if err != nil {
+		return nil, lineError(line, "failed to include source. failed to load file '%s': %v", fileName, err)
+	}
+
+	err = ld.enter(path)
+	if err != nil {
+		return nil, lineError(line, "failed to include source. %v", err)
+	}
+	defer ld.leave()
+
+	return ld.process(types.SplitSource(displayName(path), content), depth + 1)
+}
+
+// Loads and processes the std module named by an '@import' on line, e.g. 'std/strings'.
+// Modules follow the same once-only and cycle rules as includes.
+func (ld *loader) importModule(line types.SourceLine, name string, depth int) ([]types.SourceLine, error) {
+	module, ok := strings.CutPrefix(name, stdPrefix)
+	if !ok {
+		return nil, lineError(line, "failed to import module. '%s' is not a std module. use @include for files.", name)
+	}
+
+	key := moduleKey(module)
+	if idx := slices.IndexFunc(ld.chain, func(file includedFile) bool { return file.key == key }); idx != -1 {
+		return nil, lineError(line, "failed to import module. import cycle: %s.", ld.trace(idx, key))
+	}
+
+	if ld.included[key] {
+		return nil, nil
+	}
+
+	if depth >= ld.maxDepth {
+		return nil, lineError(line, "failed to import module. include depth limit of %d reached: %s.", ld.maxDepth, ld.trace(0, key))
+	}
+
+	source, err := stdlib.GetModule(module)
+	if err != nil {
+		if suggestion, ok := util.Suggest(module, stdlib.Modules()); ok {
+			return nil, lineError(line, "failed to import module. %v did you mean '%s%s'?", err, stdPrefix, suggestion)
+		}
+
+		return nil, lineError(line, "failed to import module. %v", err)
+	}
+
+	ld.push(key, key)
+	defer ld.leave()
+
+	return ld.process(source, depth + 1)
+}
+
+// Std modules are keyed by their import name, which can never collide with an absolute file path.
+func moduleKey(module string) string {
+	return stdPrefix + module
+}
+
+// Finds the file an '@include' in from refers to.
+//   - '/<file>' is relative to the git root.
+//   - './<file>' and '../<file>' are relative to the including file.
+//   - any other name is tried relative to the including file, then in every search path.
+func (ld *loader) resolve(from, fileName string) (string, error) {
+	if strings.HasPrefix(fileName, "/") {
+		git, err := tools.LocateGit()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve '%s'. failed to locate git: %v", fileName, err)
+		}
+
+		return existing(filepath.Join(git, fileName), fileName)
+	}
+
+	local := filepath.Join(filepath.Dir(from), fileName)
+	if strings.HasPrefix(fileName, "./") || strings.HasPrefix(fileName, "../") {
+		return existing(local, fileName)
+	}
+
+	if _, err := os.Stat(local); err == nil {
+		return local, nil
+	}
+
+	for _, dir := range ld.searchPaths {
+		path := filepath.Join(dir, fileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	if len(ld.searchPaths) == 0 {
+		return "", fmt.Errorf("file '%s' not found.", fileName)
+	}
+
+	return "", fmt.Errorf("file '%s' not found. searched %s and %s.", fileName, filepath.Dir(local), strings.Join(ld.searchPaths, ", "))
+}
+
+func existing(path, fileName string) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("file '%s' not found: %v", fileName, err)
+	}
+
+	return path, nil
+}
+
+// Renders the include chain from idx onward, ending with name, e.g. "init.wet -> a.wet -> init.wet".
+func (ld *loader) trace(idx int, name string) string {
+	names := make([]string, 0, len(ld.chain) - idx + 1)
+	for _, file := range ld.chain[idx:] {
+		names = append(names, file.name)
+	}
+
+	return strings.Join(append(names, name), " -> ")
+}
+
+// Identifies a file regardless of the path used to reach it.
+func fileKey(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve '%s': %v", path, err)
+	}
+
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = real
+	}
+
+	return abs, nil
+}
+
+// Included files are named relative to the entry script's directory.
+func displayName(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+
+	rel, err := filepath.Rel(cwd, abs)
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+
+	return filepath.ToSlash(rel)
+}
@@ -6,51 +6,66 @@ import (
 	"strings"
 
 	"github.com/ktnuity/wet/internal/stdlib"
+	"github.com/ktnuity/wet/internal/tokenizer"
 	"github.com/ktnuity/wet/internal/types"
 	"github.com/ktnuity/wet/internal/util"
 )
 
 type ExitCallback = func()
 
-func Load(args *types.WetArgs) (string, ExitCallback) {
-	std, err := stdlib.GetContent()
+func Load(args *types.WetArgs) ([]types.SourceLine, ExitCallback) {
+	std, err := stdlib.GetPrelude()
 	util.ExitWithError(err, util.AsRef("Failed to load STD Lib"))
 
 	var exit ExitCallback = func() {}
 
+	ld := newLoader(args)
+	ld.included[moduleKey(stdlib.Prelude)] = true
+
 	var sourcePath *string
 
-	var inputSource string
-	var source string
+	var inputSource []types.SourceLine
+	var source []types.SourceLine
 
-	if args.Flags.Is(types.WetFlagHelp) {
-		inputSource = "help\n"
-	} else if args.Flags.Is(types.WetFlagVersion) {
-		inputSource = "version\n"
-	} else if args.Flags.Is(types.WetFlagLicense) {
-		inputSource = "license\n"
-	} else if args.Path == nil {
+	switch args.Command {
+	case types.WetCommandHelp:
+		// The command overview is generated from the command table, so it follows the std help text.
 		exit = func() {
-			usage(args.Bin.Name)
+			fmt.Printf("\n%s", util.Overview(args.Bin.Name))
 		}
-		inputSource = "version\n"
-	} else {
+		inputSource = types.SplitSource("<cli>", "@import std/help\nhelp\n")
+	case types.WetCommandVersion:
+		inputSource = types.SplitSource("<cli>", "@import std/version\nversion\n")
+	case types.WetCommandLicense:
+		inputSource = types.SplitSource("<cli>", "@import std/license\nlicense\n")
+	default:
 		sourcePath = args.Path
 
+		// Search paths are relative to where wet was started, so they are resolved before moving to the script.
+		searchPaths, err := includePaths(args.IncludePaths)
+		util.ExitWithError(err, util.AsRef("Failed to resolve include paths"))
+		ld.searchPaths = searchPaths
+
 		lastIndex := strings.LastIndex(strings.ReplaceAll(*sourcePath, "\\", "/"), "/")
-		dir := (*sourcePath)[:lastIndex]
+		dir := (*sourcePath)[:lastIndex + 1]
 		if dir != "" {
 			err = os.Chdir(dir)
 			util.ExitWithError(err, util.AsRef("Failed to change directory"))
 		}
 
-		inputSource, err = loadFile((*sourcePath)[lastIndex+1:])
+		fileName := (*sourcePath)[lastIndex+1:]
+		content, err := loadFile(fileName)
 		util.ExitWithError(err, util.AsRef("Failed to load input source"))
+
+		err = ld.enter(fileName)
+		util.ExitWithError(err, util.AsRef("Failed to load input source"))
+
+		inputSource = types.SplitSource(fileName, content)
 	}
 
-	inputSourceWithStd := fmt.Sprintf("%s\n%s", std, inputSource)
+	inputSourceWithStd := append(std, inputSource...)
 
-	source, err = processSource(inputSourceWithStd, 4, args)
+	source, err = ld.process(inputSourceWithStd, 1)
 	if sourcePath != nil {
 		util.ExitWithError(err, util.AsRef(fmt.Sprintf("Failed to load file: %s", *sourcePath)))
 	} else {
@@ -60,10 +75,6 @@ func Load(args *types.WetArgs) (string, ExitCallback) {
 	return source, exit
 }
 
-func usage(name string) {
-	fmt.Printf("Usage: %s [options] <file>\n", name)
-}
-
 func loadFile(path string) (string, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -72,51 +83,73 @@ func loadFile(path string) (string, error) {
 	return string(data), nil
 }
 
-func processSource(file string, maxDepth int, args *types.WetArgs) (string, error) {
-	if maxDepth <= 0 {
-		return "", fmt.Errorf("failed to load source. max depth reached.")
-	}
+// Expands includes and resolves conditional directives. depth is the include depth of lines,
+// which is 1 for the entry script.
+func (ld *loader) process(lines []types.SourceLine, depth int) ([]types.SourceLine, error) {
+	result := make([]types.SourceLine, 0, len(lines))
+	conds := &conditions{}
+	continued := tokenizer.ContinuedLines(lines)
+
+	for idx, line := range lines {
+		name, params, ok := parseDirective(line)
+		if !ok || continued[idx] {
+			if conds.active() {
+				result = append(result, line)
+			}
 
-	lines := strings.Split(file, "\n")
-	result := make([]string, 0, len(lines))
+			continue
+		}
 
-	for _, line := range lines {
-		if strings.HasPrefix(line, "@include ") {
-			fileName := line[9:]
+		handled, err := conds.apply(line, name, params, ld.defines)
+		if err != nil {
+			return nil, err
+		}
 
-			recSource, err := includeSource(fileName, args)
+		if handled || !conds.active() {
+			continue
+		}
+
+		switch name {
+		case "@include":
+			procSource, err := ld.include(line, strings.Join(params, " "), depth)
 			if err != nil {
-				return "", fmt.Errorf("failed to load source: %v", err)
+				return nil, err
 			}
 
-			procSource, err := processSource(recSource, maxDepth - 1, args)
+			result = append(result, procSource...)
+		case "@import":
+			procSource, err := ld.importModule(line, strings.Join(params, " "), depth)
 			if err != nil {
-				return "", fmt.Errorf("failed to process source: %v", err)
+				return nil, err
 			}
 
-			result = append(result, procSource)
-		} else {
-			result = append(result, line)
+			result = append(result, procSource...)
+		case "@define":
+			err := define(line, params, ld.defines, ld.args.Defines)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, lineError(line, "unknown directive '%s'.", name)
 		}
 	}
 
-	return strings.Join(result, "\n"), nil
-}
-
-func includeSource(fileName string, args *types.WetArgs) (string, error) {
-	if !strings.HasSuffix(fileName, ".wet") {
-		return "", fmt.Errorf("failed to include source. file '%s' has invalid suffix.", fileName)
-	}
-
-	_, err := os.Stat(fileName)
+	err := conds.close()
 	if err != nil {
-		return "", fmt.Errorf("failed to include source. file '%s' not found: %v", fileName, err)
+		return nil, err
 	}
 
-	source, err := loadFile(fileName)
-	if err != nil {
-		return "", fmt.Errorf("failed to include source. failed to load file '%s': %v", fileName, err)
+	return result, nil
+}
+
+func lineError(line types.SourceLine, format string, args...any) error {
+	pos := types.Position{
+		File: line.File,
+		Line: line.Line,
+		Col: 1,
+		Text: line.Text,
+		Std: line.Std,
 	}
 
-	return source, nil
+	return types.NewSourceError(pos, format, args...)
 }
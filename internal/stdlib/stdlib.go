@@ -9,29 +9,56 @@ import (
 	"io/fs"
 	"regexp"
 	"strings"
+
+	"github.com/ktnuity/wet/internal/types"
 )
 
 //go:embed std/*
 var stdFS embed.FS
 
-func GetContent() (string, error) {
-	files, err := listFiles()
+// The prelude is loaded ahead of every script. Everything else is imported with '@import std/<name>'.
+const Prelude = "prelude"
+
+// GetPrelude returns the source of the default prelude.
+func GetPrelude() ([]types.SourceLine, error) {
+	return GetModule(Prelude)
+}
+
+// GetModule returns the source of the std module name, e.g. "strings" for std/strings.
+func GetModule(name string) ([]types.SourceLine, error) {
+	fileName := name + ".wet"
+	content, success, err := getFile(fileName)
 	if err != nil {
-		return "", fmt.Errorf("failed to load std: %v", err)
+		return nil, fmt.Errorf("failed to load std module '%s': %v", name, err)
+	} else if !success {
+		return nil, fmt.Errorf("std module '%s' not found.", name)
 	}
 
-	parts := make([]string, 0, len(files))
+	lines := types.SplitSource("std/" + fileName, content)
+	for idx := range lines {
+		lines[idx].Std = true
+	}
+
+	return lines, nil
+}
 
-	for _, file := range files {
-		content, success, err := getFile(file)
-		if success {
-			parts = append(parts, content)
-		} else if err != nil {
-			return "", fmt.Errorf("failed to load std file '%s': %v", file, err)
+// Modules lists the name of every std module in sorted order.
+func Modules() []string {
+	entries, err := fs.ReadDir(stdFS, "std")
+	if err != nil {
+		return nil
+	}
+
+	result := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !validFilename(entry.Name()) {
+			continue
 		}
+
+		result = append(result, strings.TrimSuffix(entry.Name(), ".wet"))
 	}
 
-	return strings.Join(parts, "\n"), nil
+	return result
 }
 
 func getFile(fileName string) (string, bool, error) {
@@ -53,37 +80,6 @@ func getFile(fileName string) (string, bool, error) {
 	return string(data), true, nil
 }
 
-func listFiles() ([]string, error) {
-	result := make([]string, 0, 10)
-
-	_, err := fs.Stat(stdFS, "std/std.txt")
-	if err != nil {
-		return result, fmt.Errorf("failed to stat std lib index.")
-	}
-
-	data, err := fs.ReadFile(stdFS, "std/std.txt")
-	if err != nil {
-		return result, fmt.Errorf("failed to open std lib index: %v", err)
-	}
-
-	input := strings.Split(string(data), "\n")
-
-	for _, line := range input {
-		fileName := strings.TrimSpace(line)
-		if len(fileName) == 0 {
-			continue
-		}
-
-		if !validFilename(fileName) {
-			continue
-		}
-
-		result = append(result, fileName)
-	}
-
-	return result, nil
-}
-
 func validFilename(fileName string) bool {
 	matched, _ := regexp.MatchString(`^[a-z]+\.wet$`, fileName)
 	return matched
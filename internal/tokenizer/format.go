@@ -0,0 +1,100 @@
+package tokenizer
+
+import (
+	"strings"
+
+	"github.com/ktnuity/wet/internal/types"
+)
+
+const indentUnit = "    "
+
+// Format re-indents a script by its block structure, one level per open block.
+// Trailing whitespace is removed, and runs of blank lines are collapsed into one.
+// Lines continuing a string or block comment are kept as they are.
+func Format(file, text string) (string, error) {
+	lines := types.SplitSource(file, text)
+	scan := &scanner{
+		lines: lines,
+		continued: make(map[int]bool),
+	}
+
+	tokens, err := tokenize(scan)
+	if err != nil {
+		return "", err
+	}
+
+	firsts := make(map[int]*types.Token)
+	deltas := make([]int, len(lines))
+	for idx := range tokens {
+		token := &tokens[idx]
+		line := token.Pos.Line - 1
+
+		if _, ok := firsts[line]; !ok {
+			firsts[line] = token
+		}
+
+		deltas[line] += blockDelta(token)
+	}
+
+	var sb strings.Builder
+	depth := 0
+	blank := false
+
+	for idx, line := range lines {
+		if scan.continued[idx] {
+			sb.WriteString(line.Text)
+			sb.WriteByte('\n')
+			depth = max(0, depth + deltas[idx])
+			continue
+		}
+
+		text := strings.TrimSpace(line.Text)
+		if text == "" {
+			blank = sb.Len() > 0
+			continue
+		}
+
+		if blank {
+			sb.WriteByte('\n')
+			blank = false
+		}
+
+		indent := depth
+		if first, ok := firsts[idx]; ok && closesLine(first) {
+			indent = max(0, indent - 1)
+		}
+
+		sb.WriteString(strings.Repeat(indentUnit, indent))
+		sb.WriteString(text)
+		sb.WriteByte('\n')
+
+		depth = max(0, depth + deltas[idx])
+	}
+
+	return sb.String(), nil
+}
+
+// Blocks open at their first keyword and close at 'end'.
+func blockDelta(token *types.Token) int {
+	if token.Type == types.TokenTypeNone && token.Value == "macro" {
+		return 1
+	}
+
+	if token.Type != types.TokenTypeKeyword {
+		return 0
+	}
+
+	switch token.Value {
+	case "if", "unless", "while", "until", "each", "proc":
+		return 1
+	case "end":
+		return -1
+	}
+
+	return 0
+}
+
+// Lines starting with these keywords line up with the keyword that opened the block.
+func closesLine(token *types.Token) bool {
+	return token.Type == types.TokenTypeKeyword && (token.Value == "end" || token.Value == "else" || token.Value == "do")
+}
@@ -2,24 +2,56 @@ package tokenizer
 
 import (
 	"fmt"
+	"maps"
 	"regexp"
+	"slices"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/ktnuity/wet/internal/types"
-	"github.com/ktnuity/wet/internal/util"
 )
 
-func TokenizeCode(input string) ([]types.Token) {
-	result := make([]types.Token, 0, 8)
+type scanner struct {
+	lines		[]types.SourceLine
+	line		int
+	text		string
+	offset		int
+	// Indexes of lines that start inside a string or block comment.
+	continued	map[int]bool
+}
+
+func TokenizeCode(lines []types.SourceLine) ([]types.Token, error) {
+	return tokenize(&scanner{
+		lines: lines,
+	})
+}
 
-	commentLess := stripComments(input)
-	code := stripExcessWhitespace(commentLess)
+// ContinuedLines returns the indexes of lines that start inside a string or block comment. Source
+// that fails to tokenize has none, so its error is left for TokenizeCode to report.
+func ContinuedLines(lines []types.SourceLine) map[int]bool {
+	scan := &scanner{
+		lines: lines,
+		continued: make(map[int]bool),
+	}
 
-	var scan string = code
+	_, err := tokenize(scan)
+	if err != nil {
+		return nil
+	}
+
+	return scan.continued
+}
+
+func tokenize(scan *scanner) ([]types.Token, error) {
+	result := make([]types.Token, 0, 8)
 
 	for {
-		nextScan, word := nextWord(scan)
-		if nextScan == nil {
+		pos, word, ok, err := scan.nextWord()
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
 			break
 		}
 
@@ -27,12 +59,11 @@ func TokenizeCode(input string) ([]types.Token) {
 		result = append(result, types.Token{
 			Value: word,
 			Type: tokenType,
+			Pos: pos,
 		})
-
-		scan = *nextScan
 	}
 
-	return result
+	return result, nil
 }
 
 func LogTokens(tokens []types.Token) error {
@@ -44,128 +75,231 @@ func LogTokens(tokens []types.Token) error {
 
 	for idx, token := range tokens {
 		format := token.Format()
-		fmt.Printf("%d : %s\n", idx, format)
+		fmt.Printf("%d : %s (%s)\n", idx, format, token.Pos.String())
 	}
 
 	return nil
 }
 
-func stripComments(str string) string {
-	lines := strings.Split(str, "\n")
-	result := make([]string, 0, len(lines))
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\r'
+}
+
+func (s *scanner) position() types.Position {
+	line := s.lines[s.line]
 
-	for _, line := range lines {
-		if !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "//") {
-			result = append(result, line)
-		}
+	return types.Position{
+		File: line.File,
+		Line: line.Line,
+		Col: utf8.RuneCountInString(line.Text[:s.offset]) + 1,
+		Text: line.Text,
+		Std: line.Std,
 	}
+}
 
-	return strings.Join(result, "\n")
+func (s *scanner) markContinued() {
+	if s.continued != nil {
+		s.continued[s.line] = true
+	}
 }
 
-func stripExcessWhitespace(str string) string {
-	var result strings.Builder
-	result.Grow(len(str))
+func (s *scanner) eof() bool {
+	return s.line >= len(s.lines)
+}
 
-	inString := false
-	escaped := false
-	prevWasSpace := false
+// Line comments start with '#' or '//' and run to the end of the line.
+func (s *scanner) atLineComment() bool {
+	return s.text[s.offset] == '#' || strings.HasPrefix(s.text[s.offset:], "//")
+}
 
-	for _, ch := range str {
-		if escaped {
-			result.WriteRune(ch)
-			escaped = false
-			prevWasSpace = false
-			continue
-		}
+func (s *scanner) atBlockComment() bool {
+	return strings.HasPrefix(s.text[s.offset:], "/*")
+}
 
-		if ch == '\\' && inString {
-			escaped = true
-			result.WriteRune(ch)
-			prevWasSpace = false
-			continue
-		}
+// Skips a '/* ... */' comment, crossing lines if needed.
+func (s *scanner) skipBlockComment() error {
+	pos := s.position()
+	s.offset += 2
 
-		if ch == '"' {
-			inString = !inString
-			result.WriteRune(ch)
-			prevWasSpace = false
-			continue
+	for !s.eof() {
+		s.text = s.lines[s.line].Text
+
+		if end := strings.Index(s.text[s.offset:], "*/"); end != -1 {
+			s.offset += end + 2
+			return nil
 		}
 
-		if inString {
-			result.WriteRune(ch)
-			prevWasSpace = false
-			continue
+		s.line++
+		s.offset = 0
+		s.markContinued()
+	}
+
+	return types.NewSourceError(pos, "unterminated block comment. missing closing '*/'.")
+}
+
+// Moves the scanner to the start of the next word, crossing lines if needed.
+// Comments are skipped like whitespace.
+func (s *scanner) skipWhitespace() error {
+	for !s.eof() {
+		s.text = s.lines[s.line].Text
+
+		for s.offset < len(s.text) && isSpace(s.text[s.offset]) {
+			s.offset++
 		}
 
-		// Outside string: collapse whitespace
-		isSpace := ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
-		if isSpace {
-			if !prevWasSpace {
-				result.WriteRune(' ')
-				prevWasSpace = true
+		if s.offset < len(s.text) {
+			if s.atLineComment() {
+				s.offset = len(s.text)
+				continue
+			} else if s.atBlockComment() {
+				err := s.skipBlockComment()
+				if err != nil {
+					return err
+				}
+				continue
 			}
-		} else {
-			result.WriteRune(ch)
-			prevWasSpace = false
+
+			return nil
 		}
+
+		s.line++
+		s.offset = 0
 	}
 
-	return strings.TrimSpace(result.String())
+	return nil
 }
 
-func nextWord(str string) (*string, string) {
-	str = strings.TrimSpace(str)
-	if len(str) == 0 {
-		return nil, ""
+func (s *scanner) nextWord() (types.Position, string, bool, error) {
+	err := s.skipWhitespace()
+	if err != nil {
+		return types.Position{}, "", false, err
 	}
 
-	if str[0] == '"' {
-		escaped := false
-		for i := 1; i < len(str); i++ {
-			if escaped {
-				escaped = false
-				continue
-			}
-			if str[i] == '\\' {
-				escaped = true
-				continue
-			}
-			if str[i] == '"' {
-				// Found the closing quote
-				return util.AsRef(strings.TrimSpace(str[i+1:])), str[:i+1]
-			}
+	if s.eof() {
+		return types.Position{}, "", false, nil
+	}
+
+	pos := s.position()
+	ch := s.text[s.offset]
+
+	if ch == '"' {
+		word, err := s.scanString()
+		return pos, word, err == nil, err
+	} else if ch == '.' || ch == '/' || ch == ':' {
+		word, err := s.scanPath()
+		return pos, word, err == nil, err
+	}
+
+	start := s.offset
+	for s.offset < len(s.text) && !isSpace(s.text[s.offset]) && !s.atLineComment() && !s.atBlockComment() {
+		err := s.checkStray()
+		if err != nil {
+			return types.Position{}, "", false, err
 		}
-		// No closing quote found - return entire string as error token
-		return util.AsRef(""), str
-	} else if str[0] == '.' || str[0] == '/' || str[0] == ':' {
-		escaped := false
-		for i := 1; i < len(str); i++ {
-			if escaped {
-				escaped = false
+
+		s.offset++
+	}
+
+	return pos, s.text[start:s.offset], true, nil
+}
+
+// Reports characters that can never be part of a plain word.
+func (s *scanner) checkStray() error {
+	ch := s.text[s.offset]
+
+	if ch == '"' {
+		return types.NewSourceError(s.position(), "stray '\"' inside a word. strings must be separated by whitespace.")
+	} else if strings.HasPrefix(s.text[s.offset:], "*/") {
+		return types.NewSourceError(s.position(), "stray '*/' outside of a block comment.")
+	} else if ch < 0x20 || ch == 0x7f {
+		return types.NewSourceError(s.position(), "stray control character %q.", ch)
+	}
+
+	return nil
+}
+
+// Escapes accepted in strings, matching types.UnescapeString.
+func isStringEscape(ch byte) bool {
+	return ch == 'n' || ch == 't' || ch == 'r' || ch == '\\' || ch == '"'
+}
+
+// Strings may span several lines.
+func (s *scanner) scanString() (string, error) {
+	var sb strings.Builder
+
+	pos := s.position()
+	start := s.offset
+	s.offset++
+
+	for !s.eof() {
+		for s.offset < len(s.text) {
+			ch := s.text[s.offset]
+
+			if ch == '\\' {
+				if s.offset + 1 >= len(s.text) || !isStringEscape(s.text[s.offset + 1]) {
+					return "", s.escapeError("string")
+				}
+
+				s.offset += 2
 				continue
 			}
-			if str[i] == '\\' {
-				escaped = true
-				continue
+
+			s.offset++
+
+			if ch == '"' {
+				sb.WriteString(s.text[start:s.offset])
+				return sb.String(), nil
 			}
-			if str[i] == ' ' {
-				return util.AsRef(strings.TrimSpace(str[i+1:])), strings.ReplaceAll(str[:i], "\\", "")
+		}
+
+		sb.WriteString(s.text[start:])
+		sb.WriteByte('\n')
+
+		s.line++
+		s.offset = 0
+		start = 0
+		s.markContinued()
+		if !s.eof() {
+			s.text = s.lines[s.line].Text
+		}
+	}
+
+	return "", types.NewSourceError(pos, "unterminated string. missing closing '\"'.")
+}
+
+// Paths end at the first unescaped whitespace. Escape backslashes are removed.
+// Only '\ ' and '\\' are valid escapes.
+func (s *scanner) scanPath() (string, error) {
+	var sb strings.Builder
+
+	for s.offset < len(s.text) {
+		ch := s.text[s.offset]
+
+		if ch == '\\' {
+			if s.offset + 1 >= len(s.text) || (s.text[s.offset + 1] != ' ' && s.text[s.offset + 1] != '\\') {
+				return "", s.escapeError("path")
 			}
+
+			sb.WriteByte(s.text[s.offset + 1])
+			s.offset += 2
+			continue
+		} else if isSpace(ch) {
+			break
 		}
 
-		return util.AsRef(""), str
+		sb.WriteByte(ch)
+		s.offset++
 	}
 
-	parts := strings.Fields(str)
-	if len(parts) == 0 {
-		return nil, ""
+	return sb.String(), nil
+}
+
+func (s *scanner) escapeError(kind string) error {
+	if s.offset + 1 >= len(s.text) {
+		return types.NewSourceError(s.position(), "invalid escape in %s. '\\' at end of line.", kind)
 	}
 
-	word := parts[0]
-	rest := strings.TrimSpace(str[len(word):])
-	return &rest, word
+	return types.NewSourceError(s.position(), "invalid escape '\\%c' in %s.", s.text[s.offset + 1], kind)
 }
 
 var numberRegex = regexp.MustCompile(`^-?(\d+\.?\d*|\.\d+)$`)
@@ -179,19 +313,30 @@ var keywords = map[string]bool{
 	"if": true, "unless": true, "else": true,
 	"dup": true, "over": true, "swap": true, "2dup": true, "2swap": true, "drop": true, "nop": true,
 	"store": true, "load": true,
-	"download": true, "move": true, "copy": true, "exist": true, "touch": true, "mkdir": true, "rm": true, "readfile": true,
+	"download": true, "downloadsum": true, "downloadwith": true, "hash": true, "move": true, "copy": true, "exist": true, "touch": true, "mkdir": true, "rm": true, "readfile": true,
+	"exec": true, "execwith": true, "http": true,
 	"unzip": true, "lsf": true, "getf": true, "lsd": true, "getd": true,
 	"concat": true, "tostring": true, "token": true, "absolute": true, "relative": true,
 	"true": true, "false": true,
 	"puts": true,
-	"int": true, "string": true,
+	"int": true, "float": true, "string": true,
+	"len": true, "nth": true, "append": true, "each": true, "join": true, "split": true,
+	"get": true, "set": true, "has": true, "keys": true,
+	"argc": true, "argv": true, "args": true,
+	"getenv": true, "setenv": true, "unsetenv": true, "env": true, "loadenv": true,
 	"exit": true,
+	"proc": true, "return": true,
 }
 
 func isKeyword(str string) bool {
 	return keywords[str]
 }
 
+// Keywords lists every keyword in sorted order.
+func Keywords() []string {
+	return slices.Sorted(maps.Keys(keywords))
+}
+
 func isString(str string) bool {
 	return len(str) >= 2 && str[0] == '"' && str[len(str)-1] == '"'
 }
@@ -206,6 +351,7 @@ var symbols = map[string]bool{
 	"&&": true, "||": true,
 	"=": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true, "!": true,
 	".": true,
+	"[": true, "]": true, "{": true, "}": true,
 }
 
 func isSymbol(str string) bool {
@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode"
+)
+
+// Prefix of the variables holding an Authorization header per host, e.g. WET_AUTH_EXAMPLE_COM.
+const authEnvPrefix = "WET_AUTH_"
+
+// Names the netrc file read instead of ~/.netrc.
+const netrcEnv = "NETRC"
+
+// Sets the Authorization header of req, unless the script already set one. The header comes from
+// WET_AUTH_<HOST>, or from a netrc entry of the host as basic auth.
+func authorize(req *http.Request) {
+	if req.Header.Get("Authorization") != "" {
+		return
+	}
+
+	host := req.URL.Hostname()
+	if host == "" {
+		return
+	}
+
+	if value, ok := os.LookupEnv(authEnvName(host)); ok && value != "" {
+		req.Header.Set("Authorization", value)
+		return
+	}
+
+	login, password, ok := netrcLookup(host)
+	if ok {
+		req.SetBasicAuth(login, password)
+	}
+}
+
+// Upper cases host and replaces anything that isn't a letter or digit with '_'.
+func authEnvName(host string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+
+		return '_'
+	}, host)
+
+	return authEnvPrefix + name
+}
+
+func netrcPath() (string, error) {
+	if path := os.Getenv(netrcEnv); path != "" {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	if runtime.GOOS == "windows" {
+		path := filepath.Join(home, "_netrc")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return filepath.Join(home, ".netrc"), nil
+}
+
+// Finds the login and password of host in the netrc file. A 'default' entry is used when no
+// 'machine' entry names host. A missing file holds no entries.
+func netrcLookup(host string) (string, string, bool) {
+	path, err := netrcPath()
+	if err != nil {
+		return "", "", false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", false
+	}
+
+	return parseNetrc(string(data), host)
+}
+
+func parseNetrc(data, host string) (string, string, bool) {
+	type entry struct {
+		login		string
+		password	string
+	}
+
+	var found, fallback *entry
+	var current *entry
+
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for idx := 0; idx < len(lines); idx++ {
+		line := lines[idx]
+		if comment := strings.Index(line, "#"); comment >= 0 {
+			line = line[:comment]
+		}
+
+		fields := strings.Fields(line)
+		for pos := 0; pos < len(fields); pos++ {
+			switch fields[pos] {
+			case "machine":
+				current = nil
+				if pos + 1 < len(fields) {
+					pos++
+					if found == nil && fields[pos] == host {
+						found = &entry{}
+						current = found
+					}
+				}
+			case "default":
+				current = nil
+				if fallback == nil {
+					fallback = &entry{}
+					current = fallback
+				}
+			case "login", "password", "account":
+				if pos + 1 >= len(fields) {
+					continue
+				}
+
+				pos++
+				if current == nil {
+					continue
+				}
+
+				if fields[pos - 1] == "login" {
+					current.login = fields[pos]
+				} else if fields[pos - 1] == "password" {
+					current.password = fields[pos]
+				}
+			case "macdef":
+				// A macro runs until the next blank line and holds no credentials.
+				current = nil
+				for idx + 1 < len(lines) && strings.TrimSpace(lines[idx + 1]) != "" {
+					idx++
+				}
+				pos = len(fields)
+			}
+		}
+	}
+
+	if found == nil {
+		found = fallback
+	}
+
+	if found == nil || found.login == "" {
+		return "", "", false
+	}
+
+	return found.login, found.password, true
+}
@@ -0,0 +1,343 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DownloadIndexName is the file in the token dir that remembers past downloads.
+const DownloadIndexName = "downloads.json"
+
+// DownloadCacheName is the directory in the token dir holding the content of past downloads.
+const DownloadCacheName = "downloads"
+
+// Every download is hashed with this algorithm, so a copy can be recognised after its timestamp changed.
+const cacheAlgorithm = "sha256"
+
+// What is known about the last download of a url.
+type downloadEntry struct {
+	// Name of the file in the cache dir holding the content. Empty until a download completed.
+	File			string				`json:"file,omitempty"`
+	ETag			string				`json:"etag,omitempty"`
+	LastModified	string				`json:"last_modified,omitempty"`
+	Size			int64				`json:"size"`
+	// Modification time of the cached file right after the download, in nanoseconds.
+	ModTime			int64				`json:"mod_time"`
+	Hashes			map[string]string	`json:"hashes,omitempty"`
+	Fetched			time.Time			`json:"fetched"`
+	// ETag or Last-Modified of the response the .part file was started from. A .part file without
+	// one is never resumed, as there is no way to tell whether the content changed since.
+	Partial			string				`json:"partial,omitempty"`
+}
+
+// Past downloads keyed by url. A nil index, used when there is no token dir, remembers nothing.
+type downloadIndex struct {
+	path			string
+	dir				string
+	Downloads		map[string]*downloadEntry	`json:"downloads"`
+}
+
+// Reads the index from the token dir. The cache only saves work, so an index that can't be read
+// is started over rather than failing the download.
+func loadDownloadIndex() *downloadIndex {
+	dir, err := TokenDir()
+	if err != nil {
+		return nil
+	}
+
+	index := &downloadIndex{
+		path: filepath.Join(dir, DownloadIndexName),
+		dir: filepath.Join(dir, DownloadCacheName),
+		Downloads: make(map[string]*downloadEntry),
+	}
+
+	if os.MkdirAll(index.dir, 0755) != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(index.path)
+	if err != nil {
+		return index
+	}
+
+	if json.Unmarshal(data, index) != nil || index.Downloads == nil {
+		index.Downloads = make(map[string]*downloadEntry)
+	}
+
+	return index
+}
+
+func (index *downloadIndex) lookup(url string) *downloadEntry {
+	if index == nil {
+		return nil
+	}
+
+	return index.Downloads[url]
+}
+
+// Returns where the content of url is cached. The name only depends on url, so a .part file of an
+// interrupted download is found again by the next run.
+func (index *downloadIndex) file(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(index.dir, hex.EncodeToString(sum[:]))
+}
+
+// Remembers the validator of the response a .part file was started from.
+func (index *downloadIndex) setPartial(url, validator string) {
+	if index == nil {
+		return
+	}
+
+	entry, ok := index.Downloads[url]
+	if !ok {
+		entry = &downloadEntry{}
+		index.Downloads[url] = entry
+	}
+
+	entry.Partial = validator
+	index.save()
+}
+
+func (index *downloadIndex) record(url, etag, lastModified string, sums map[string]string) {
+	if index == nil {
+		return
+	}
+
+	path := index.file(url)
+	stat, err := os.Stat(path)
+	if err != nil {
+		delete(index.Downloads, url)
+		return
+	}
+
+	index.Downloads[url] = &downloadEntry{
+		File: filepath.Base(path),
+		ETag: etag,
+		LastModified: lastModified,
+		Size: stat.Size(),
+		ModTime: stat.ModTime().UnixNano(),
+		Hashes: sums,
+		Fetched: time.Now().UTC(),
+	}
+}
+
+// Writes the index through a temp file, so an interrupted run never leaves half an index behind.
+func (index *downloadIndex) save() error {
+	if index == nil {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(index, "", "\t")
+	if err != nil {
+		return fmt.Errorf("failed to save download index: %w", err)
+	}
+
+	tmp := index.path + ".tmp"
+	err = os.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to save download index: %w", err)
+	}
+
+	err = os.Rename(tmp, index.path)
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save download index: %w", err)
+	}
+
+	return nil
+}
+
+// Returns the validator of the .part file, or "" when there is none.
+func (entry *downloadEntry) partial() string {
+	if entry == nil {
+		return ""
+	}
+
+	return entry.Partial
+}
+
+// Reports whether the cached file at path is still what was downloaded. A file whose size and
+// timestamp are unchanged is trusted, otherwise its content is hashed.
+func (entry *downloadEntry) valid(path string) bool {
+	if entry == nil || entry.File == "" || entry.File != filepath.Base(path) {
+		return false
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil || stat.IsDir() || stat.Size() != entry.Size {
+		return false
+	}
+
+	if stat.ModTime().UnixNano() == entry.ModTime {
+		return true
+	}
+
+	sum, err := hashPath(path, cacheAlgorithm)
+	if err != nil || sum != entry.Hashes[cacheAlgorithm] {
+		return false
+	}
+
+	entry.ModTime = stat.ModTime().UnixNano()
+	return true
+}
+
+// Returns the digest of the cached file at path, from the entry when it was computed during the download.
+func (entry *downloadEntry) sum(path, algorithm string) (string, bool) {
+	if !entry.valid(path) {
+		return "", false
+	}
+
+	if sum, ok := entry.Hashes[algorithm]; ok {
+		return sum, true
+	}
+
+	sum, err := hashPath(path, algorithm)
+	if err != nil {
+		return "", false
+	}
+
+	return sum, true
+}
+
+// Hard-links the cached file at src to dst, so the cache costs no extra disk space. When that
+// fails, e.g. across file systems, src is copied instead. Either goes through '<dst>.part', so dst
+// is never left half written. The cache replaces its files instead of writing into them, so a link
+// never sees a later download.
+func placeFile(src, dst string) error {
+	part := dst + ".part"
+	os.Remove(part)
+
+	err := os.Link(src, part)
+	if err != nil {
+		err = copyFile(src, part)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.Rename(part, dst)
+	if err != nil {
+		os.Remove(part)
+		return fmt.Errorf("failed to move download into %s: %w", dst, err)
+	}
+
+	return nil
+}
+
+// Copies src into part, removing part again when the copy fails.
+func copyFile(src, part string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to read cached download: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(part)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", part, err)
+	}
+
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(part)
+		return fmt.Errorf("failed to write to %s: %w", part, err)
+	}
+
+	return nil
+}
+
+// CachedDownload describes a download remembered in the token dir, as listed by 'wet cache'.
+type CachedDownload struct {
+	Url			string
+	Size		int64
+	Fetched		time.Time
+}
+
+func readDownloadIndex() (*downloadIndex, error) {
+	dir, err := TokenDir()
+	if err != nil {
+		return nil, err
+	}
+
+	index := &downloadIndex{
+		path: filepath.Join(dir, DownloadIndexName),
+		dir: filepath.Join(dir, DownloadCacheName),
+	}
+
+	data, err := os.ReadFile(index.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read download index: %w", err)
+	}
+
+	err = json.Unmarshal(data, index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read download index: %w", err)
+	}
+
+	return index, nil
+}
+
+// CachedDownloads lists the completed downloads sorted by url.
+func CachedDownloads() ([]CachedDownload, error) {
+	index, err := readDownloadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]CachedDownload, 0, len(index.Downloads))
+	for url, entry := range index.Downloads {
+		if entry.File == "" {
+			continue
+		}
+
+		result = append(result, CachedDownload{
+			Url: url,
+			Size: entry.Size,
+			Fetched: entry.Fetched,
+		})
+	}
+
+	slices.SortFunc(result, func(a, b CachedDownload) int { return strings.Compare(a.Url, b.Url) })
+	return result, nil
+}
+
+// ClearDownloads removes the download index and every cached download, leaving token files alone.
+// Returns how many completed downloads were forgotten.
+func ClearDownloads() (int, error) {
+	downloads, err := CachedDownloads()
+	if err != nil {
+		return 0, err
+	}
+
+	index, err := readDownloadIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	err = os.RemoveAll(index.dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear downloads: %w", err)
+	}
+
+	err = os.Remove(index.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf("failed to clear downloads: %w", err)
+	}
+
+	return len(downloads), nil
+}
@@ -1,38 +1,462 @@
 package tools
 
 import (
+	"context"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ktnuity/wet/internal/types"
 )
 
+// Longest wait between two attempts, however many attempts failed before.
+const maxBackoff = 30 * time.Second
+
+// DownloadConfig holds the settings used by every download. Tests swap Client and Sleep to run
+// against an httptest server without waiting.
+type DownloadConfig struct {
+	Client		*http.Client
+	// How long to wait for a response, or for more data once the body is streaming. 0 waits forever.
+	Timeout		time.Duration
+	// Attempts made after the first one fails.
+	Retries		int
+	// Wait before the first retry. Every further retry waits twice as long.
+	Backoff		time.Duration
+	Sleep		func(time.Duration)
+	// Where the progress bar is drawn. nil draws none.
+	Progress	io.Writer
+	// Downloads again without asking the server whether cached downloads changed.
+	Refresh		bool
+}
+
+// DefaultDownloadConfig returns the settings used when none are submitted. The progress bar is
+// only drawn when stderr is a terminal.
+func DefaultDownloadConfig() DownloadConfig {
+	var progress io.Writer
+	if isTerminal(os.Stderr) {
+		progress = os.Stderr
+	}
+
+	return DownloadConfig{
+		Client: http.DefaultClient,
+		Timeout: types.DefaultDownloadTimeout * time.Second,
+		Retries: types.DefaultDownloadRetries,
+		Backoff: time.Second,
+		Sleep: time.Sleep,
+		Progress: progress,
+	}
+}
+
+var downloadConfig = DefaultDownloadConfig()
+
+func SubmitDownloadConfig(cfg DownloadConfig) {
+	downloadConfig = cfg
+}
+
+func isTerminal(file *os.File) bool {
+	stat, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return stat.Mode() & os.ModeCharDevice != 0
+}
+
 func ToolDownload(url, dst string) error {
+	return download(url, dst, nil, nil)
+}
+
+// ToolDownloadWith downloads like ToolDownload, sending headers with every request.
+func ToolDownloadWith(url, dst string, headers map[string]string) error {
+	return download(url, dst, nil, headers)
+}
+
+// ToolDownloadVerified downloads like ToolDownload, but only places the file at dst when its content
+// matches digest. A mismatch is returned as a *ChecksumError.
+func ToolDownloadVerified(url, dst, digest string) error {
+	expected, err := ParseDigest(digest)
+	if err != nil {
+		return fmt.Errorf("failed to download from %s: %w", url, err)
+	}
+
+	return download(url, dst, &expected, nil)
+}
+
+// Streams the response into a .part file, hashing it on the way. Failed attempts are retried with
+// backoff, and resume from what the .part file already holds. With a token dir, the content is
+// kept in its download cache and copied to dst, and a cached copy is only downloaded again when the
+// server reports a change. Without one, '<dst>.part' is renamed to dst once complete and verified.
+func download(url, dst string, expected *Digest, headers map[string]string) error {
 	path, err := fixPath(dst)
 	if err != nil {
 		return fmt.Errorf("failed to download from %s: %w", url, err)
 	}
 
-	resp, err := http.Get(url)
+	index := loadDownloadIndex()
+	entry := index.lookup(url)
+
+	var cached string
+	if index != nil {
+		cached = index.file(url)
+	}
+
+	// Content matching the pinned digest can't be any fresher, so it is used without asking the server.
+	if expected != nil {
+		if sum, err := hashPath(path, expected.Algorithm); err == nil && sum == expected.Sum {
+			return nil
+		}
+
+		if sum, ok := entry.sum(cached, expected.Algorithm); ok && sum == expected.Sum {
+			index.save()
+			return placeFile(cached, path)
+		}
+	}
+
+	f := &fetcher{
+		cfg: downloadConfig,
+		url: url,
+		part: path + ".part",
+		name: filepath.Base(path),
+		headers: headers,
+		algorithms: []string{cacheAlgorithm},
+	}
+
+	if index != nil {
+		f.part = cached + ".part"
+		f.index = index
+		f.validator = entry.partial()
+	}
+
+	if expected != nil && expected.Algorithm != cacheAlgorithm {
+		f.algorithms = append(f.algorithms, expected.Algorithm)
+	}
+
+	// A cached copy that failed its digest must be replaced, so the server isn't asked whether it changed.
+	if expected == nil && !downloadConfig.Refresh && entry.valid(cached) {
+		f.cached = entry
+	}
+
+	sums, err := f.run()
 	if err != nil {
 		return fmt.Errorf("failed to download from %s: %w", url, err)
 	}
+
+	if f.notModified {
+		index.save()
+		return placeFile(cached, path)
+	}
+
+	if expected != nil && sums[expected.Algorithm] != expected.Sum {
+		os.Remove(f.part)
+		return &ChecksumError{
+			Url: url,
+			Expected: *expected,
+			Actual: Digest{Algorithm: expected.Algorithm, Sum: sums[expected.Algorithm]},
+		}
+	}
+
+	if index == nil {
+		err = os.Rename(f.part, path)
+		if err != nil {
+			return fmt.Errorf("failed to move download into %s: %w", dst, err)
+		}
+
+		return nil
+	}
+
+	err = os.Rename(f.part, cached)
+	if err != nil {
+		return fmt.Errorf("failed to move download into the cache: %w", err)
+	}
+
+	index.record(url, f.etag, f.lastModified, sums)
+	index.save()
+	return placeFile(cached, path)
+}
+
+// State of one download across its attempts.
+type fetcher struct {
+	cfg			DownloadConfig
+	url			string
+	part		string
+	name		string
+	headers		map[string]string
+	algorithms	[]string
+	// Where the validator of the .part file is kept between runs. nil keeps it for this run only.
+	index		*downloadIndex
+	// ETag or Last-Modified of the response the .part file was started from, so a resumed range
+	// is only accepted from the same content.
+	validator	string
+	etag			string
+	lastModified	string
+	// Entry of a cached copy. The server is asked whether it changed.
+	cached		*downloadEntry
+	notModified	bool
+}
+
+// Attempts the download until it succeeds, fails for good or runs out of retries. Returns the hex
+// digest of the whole file for every algorithm.
+func (f *fetcher) run() (map[string]string, error) {
+	var lastErr error
+	for attempt := 0; attempt <= f.cfg.Retries; attempt++ {
+		if attempt > 0 {
+			f.cfg.Sleep(backoff(f.cfg.Backoff, attempt))
+		}
+
+		sums, retry, err := f.fetch()
+		if err == nil {
+			return sums, nil
+		}
+
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+
+	if f.cfg.Retries > 0 {
+		return nil, fmt.Errorf("gave up after %d attempt(s): %w", f.cfg.Retries + 1, lastErr)
+	}
+
+	return nil, lastErr
+}
+
+func backoff(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for range attempt - 1 {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+
+	return delay
+}
+
+// Makes a single attempt. Returns whether a failure is worth retrying.
+func (f *fetcher) fetch() (map[string]string, bool, error) {
+	var offset int64
+	if stat, err := os.Stat(f.part); err == nil {
+		offset = stat.Size()
+	}
+
+	// Without a validator, the server can't tell whether the content changed since the .part file
+	// was started, so a range could join old and new bytes.
+	if offset > 0 && f.validator == "" {
+		os.Remove(f.part)
+		offset = 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var timedOut atomic.Bool
+	var timer *time.Timer
+	if f.cfg.Timeout > 0 {
+		timer = time.AfterFunc(f.cfg.Timeout, func() {
+			timedOut.Store(true)
+			cancel()
+		})
+		defer timer.Stop()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for name, value := range f.headers {
+		req.Header.Set(name, value)
+	}
+	authorize(req)
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if f.validator != "" {
+			req.Header.Set("If-Range", f.validator)
+		}
+	} else if f.cached != nil {
+		if f.cached.ETag != "" {
+			req.Header.Set("If-None-Match", f.cached.ETag)
+		}
+
+		if f.cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", f.cached.LastModified)
+		}
+	}
+
+	resp, err := f.cfg.Client.Do(req)
+	if err != nil {
+		return nil, true, f.timeoutError(timedOut.Load(), err)
+	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed with status %d: %s", resp.StatusCode, resp.Status)
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if f.cached != nil && offset == 0 {
+			f.notModified = true
+			return nil, false, nil
+		}
+
+		return nil, false, fmt.Errorf("server answered 304 without a cached copy.")
+	case http.StatusOK:
+		offset = 0
+	case http.StatusPartialContent:
+		if start, ok := rangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			os.Remove(f.part)
+			return nil, true, fmt.Errorf("server resumed at the wrong offset. starting over.")
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		os.Remove(f.part)
+		return nil, true, fmt.Errorf("server rejected the resume range. starting over.")
+	default:
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+		return nil, retry, fmt.Errorf("download failed with status %d: %s", resp.StatusCode, resp.Status)
 	}
 
-	out, err := os.Create(path)
+	f.etag = resp.Header.Get("ETag")
+	f.lastModified = resp.Header.Get("Last-Modified")
+	if offset == 0 {
+		f.validator = f.etag
+		if f.validator == "" {
+			f.validator = f.lastModified
+		}
+
+		f.index.setPartial(f.url, f.validator)
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	out, err := os.OpenFile(f.part, flags, 0644)
 	if err != nil {
-		return fmt.Errorf("failed to create file %s: %w", dst, err)
+		return nil, false, fmt.Errorf("failed to create file %s: %w", f.part, err)
 	}
 	defer out.Close()
 
-	_, err = io.Copy(out, resp.Body)
+	writers := []io.Writer{out}
+
+	hashes := make(map[string]hash.Hash, len(f.algorithms))
+	for _, algorithm := range f.algorithms {
+		h, err := newHash(algorithm)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if offset > 0 {
+			err = hashFile(h, f.part)
+			if err != nil {
+				return nil, false, err
+			}
+		}
+
+		hashes[algorithm] = h
+		writers = append(writers, h)
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+
+	if f.cfg.Progress != nil {
+		bar := &progress{out: f.cfg.Progress, name: f.name, done: offset, total: total}
+		defer bar.finish()
+		writers = append(writers, bar)
+	}
+
+	var body io.Reader = resp.Body
+	if timer != nil {
+		body = &stallReader{reader: resp.Body, timer: timer, timeout: f.cfg.Timeout}
+	}
+
+	written, err := io.Copy(io.MultiWriter(writers...), body)
 	if err != nil {
-		return fmt.Errorf("failed to write to %s: %w", dst, err)
+		return nil, true, f.timeoutError(timedOut.Load(), fmt.Errorf("failed to write to %s: %w", f.part, err))
+	}
+
+	if resp.ContentLength >= 0 && written != resp.ContentLength {
+		return nil, true, fmt.Errorf("connection closed after %d of %d bytes.", written, resp.ContentLength)
+	}
+
+	err = out.Close()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to write to %s: %w", f.part, err)
+	}
+
+	sums := make(map[string]string, len(hashes))
+	for algorithm, h := range hashes {
+		sums[algorithm] = hex.EncodeToString(h.Sum(nil))
+	}
+
+	return sums, false, nil
+}
+
+func (f *fetcher) timeoutError(timedOut bool, err error) error {
+	if timedOut {
+		return fmt.Errorf("timed out after %s without data: %w", f.cfg.Timeout, err)
+	}
+
+	return err
+}
+
+// Reads the first byte offset of a 'bytes <start>-<end>/<size>' Content-Range.
+func rangeStart(contentRange string) (int64, bool) {
+	spec, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return 0, false
+	}
+
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+
+	value, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return value, true
+}
+
+func hashFile(h hash.Hash, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	_, err = io.Copy(h, file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
 	}
 
 	return nil
 }
+
+// Restarts the timeout on every read, so the timeout only trips when the body stalls.
+type stallReader struct {
+	reader		io.Reader
+	timer		*time.Timer
+	timeout		time.Duration
+}
+
+func (r *stallReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+
+	return n, err
+}
@@ -0,0 +1,354 @@
+package tools
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var payload = bytes.Repeat([]byte("0123456789abcdef"), 4096)
+
+// Runs the test in a fresh git root with its own token dir. Downloads use client and record their
+// backoff in the returned slice instead of sleeping.
+func setupDownload(t *testing.T, client *http.Client) (string, *[]time.Duration) {
+	dir := t.TempDir()
+	for _, name := range []string{".git", ".wet"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	sleeps := &[]time.Duration{}
+	previous := downloadConfig
+	SubmitDownloadConfig(DownloadConfig{
+		Client: client,
+		Timeout: 5 * time.Second,
+		Retries: 3,
+		Backoff: time.Second,
+		Sleep: func(d time.Duration) { *sleeps = append(*sleeps, d) },
+	})
+	t.Cleanup(func() { SubmitDownloadConfig(previous) })
+
+	return dir, sleeps
+}
+
+// Serves payload with an ETag, so ranges are honoured through If-Range.
+func servePayload(w http.ResponseWriter, r *http.Request, etag string) {
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, "payload", time.Time{}, bytes.NewReader(payload))
+}
+
+func expectFile(t *testing.T, path string, want []byte) {
+	t.Helper()
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s holds %d bytes, want %d matching bytes", path, len(got), len(want))
+	}
+}
+
+func TestDownloadRetriesWithBackoff(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+
+		servePayload(w, r, `"v1"`)
+	}))
+	defer srv.Close()
+
+	dir, sleeps := setupDownload(t, srv.Client())
+
+	err := ToolDownload(srv.URL + "/file", "/file.bin")
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+
+	expectFile(t, filepath.Join(dir, "file.bin"), payload)
+	if requests.Load() != 3 {
+		t.Errorf("made %d requests, want 3", requests.Load())
+	}
+
+	if want := []time.Duration{time.Second, 2 * time.Second}; !slices.Equal(*sleeps, want) {
+		t.Errorf("slept %v, want %v", *sleeps, want)
+	}
+}
+
+func TestDownloadGivesUp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "broken", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	dir, sleeps := setupDownload(t, srv.Client())
+
+	err := ToolDownload(srv.URL + "/file", "/file.bin")
+	if err == nil || !strings.Contains(err.Error(), "gave up after 4 attempt(s)") {
+		t.Fatalf("got error %v, want to give up after 4 attempts", err)
+	}
+
+	if len(*sleeps) != 3 {
+		t.Errorf("slept %d times, want 3", len(*sleeps))
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "file.bin")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file.bin exists after a failed download")
+	}
+}
+
+func TestDownloadResumesPart(t *testing.T) {
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if len(ranges) == 1 {
+			// Sends half of the body, then drops the connection.
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Content-Length", "65536")
+			w.Write(payload[:len(payload) / 2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+
+		if r.Header.Get("If-Range") != `"v1"` {
+			t.Errorf("resumed with If-Range %q, want %q", r.Header.Get("If-Range"), `"v1"`)
+		}
+
+		servePayload(w, r, `"v1"`)
+	}))
+	defer srv.Close()
+
+	dir, _ := setupDownload(t, srv.Client())
+
+	err := ToolDownload(srv.URL + "/file", "/file.bin")
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+
+	expectFile(t, filepath.Join(dir, "file.bin"), payload)
+	if want := []string{"", "bytes=32768-"}; !slices.Equal(ranges, want) {
+		t.Errorf("requested ranges %q, want %q", ranges, want)
+	}
+}
+
+func TestDownloadResumesPartOfEarlierRun(t *testing.T) {
+	tests := []struct {
+		name		string
+		validator	string
+		etag		string
+		wantRange	string
+	}{
+		// The server still has the content the .part file was started from.
+		{"same content", `"v1"`, `"v1"`, "bytes=1000-"},
+		// The content changed, so the server sends all of it again.
+		{"changed content", `"v1"`, `"v2"`, "bytes=1000-"},
+		// Without a validator, the .part file can't be trusted and is started over.
+		{"no validator", "", `"v1"`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ranges []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ranges = append(ranges, r.Header.Get("Range"))
+				servePayload(w, r, tt.etag)
+			}))
+			defer srv.Close()
+
+			dir, _ := setupDownload(t, srv.Client())
+
+			url := srv.URL + "/file"
+			index := loadDownloadIndex()
+			stale := bytes.Repeat([]byte("x"), 1000)
+			if tt.etag == tt.validator {
+				stale = payload[:1000]
+			}
+
+			if err := os.WriteFile(index.file(url) + ".part", stale, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.validator != "" {
+				index.setPartial(url, tt.validator)
+			}
+
+			err := ToolDownload(url, "/file.bin")
+			if err != nil {
+				t.Fatalf("download failed: %v", err)
+			}
+
+			expectFile(t, filepath.Join(dir, "file.bin"), payload)
+			if len(ranges) != 1 || ranges[0] != tt.wantRange {
+				t.Errorf("requested ranges %q, want [%q]", ranges, tt.wantRange)
+			}
+		})
+	}
+}
+
+func TestDownloadTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	setupDownload(t, srv.Client())
+	downloadConfig.Timeout = 50 * time.Millisecond
+	downloadConfig.Retries = 0
+
+	err := ToolDownload(srv.URL + "/file", "/file.bin")
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Fatalf("got error %v, want a timeout", err)
+	}
+}
+
+func TestDownloadRenamesAtomically(t *testing.T) {
+	var broken atomic.Bool
+	broken.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if broken.Load() {
+			w.Header().Set("Content-Length", "65536")
+			w.Write(payload[:100])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+
+		servePayload(w, r, `"v1"`)
+	}))
+	defer srv.Close()
+
+	dir, _ := setupDownload(t, srv.Client())
+	downloadConfig.Retries = 0
+
+	dst := filepath.Join(dir, "file.bin")
+	if err := os.WriteFile(dst, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ToolDownload(srv.URL + "/file", "/file.bin")
+	if err == nil {
+		t.Fatalf("download of a truncated body succeeded")
+	}
+
+	expectFile(t, dst, []byte("old"))
+
+	broken.Store(false)
+	err = ToolDownload(srv.URL + "/file", "/file.bin")
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+
+	expectFile(t, dst, payload)
+	for _, pattern := range []string{filepath.Join(dir, "*.part"), filepath.Join(dir, ".wet", DownloadCacheName, "*.part")} {
+		if parts, _ := filepath.Glob(pattern); len(parts) > 0 {
+			t.Errorf("left %v behind", parts)
+		}
+	}
+}
+
+func TestDownloadRevalidatesCache(t *testing.T) {
+	var conditional []bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match") != "")
+		servePayload(w, r, `"v1"`)
+	}))
+	defer srv.Close()
+
+	dir, _ := setupDownload(t, srv.Client())
+	dst := filepath.Join(dir, "file.bin")
+
+	for range 2 {
+		err := ToolDownload(srv.URL + "/file", "/file.bin")
+		if err != nil {
+			t.Fatalf("download failed: %v", err)
+		}
+
+		expectFile(t, dst, payload)
+		os.Remove(dst)
+	}
+
+	downloadConfig.Refresh = true
+	err := ToolDownload(srv.URL + "/file", "/file.bin")
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+
+	expectFile(t, dst, payload)
+	if want := []bool{false, true, false}; !slices.Equal(conditional, want) {
+		t.Errorf("conditional requests %v, want %v", conditional, want)
+	}
+}
+
+func TestDownloadLinksCachedFile(t *testing.T) {
+	etag := `"v1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		servePayload(w, r, etag)
+	}))
+	defer srv.Close()
+
+	dir, _ := setupDownload(t, srv.Client())
+	dst := filepath.Join(dir, "file.bin")
+	url := srv.URL + "/file"
+
+	err := ToolDownload(url, "/file.bin")
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+
+	cachedStat, err := os.Stat(loadDownloadIndex().file(url))
+	if err != nil {
+		t.Fatalf("download wasn't cached: %v", err)
+	}
+
+	dstStat, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !os.SameFile(cachedStat, dstStat) {
+		t.Errorf("file.bin is a copy of the cached download, want a link")
+	}
+
+	// A new download replaces the cached file, so a link kept elsewhere holds the old content.
+	kept := filepath.Join(dir, "kept.bin")
+	if err := os.Rename(dst, kept); err != nil {
+		t.Fatal(err)
+	}
+
+	etag = `"v2"`
+	downloadConfig.Refresh = true
+	err = ToolDownload(url, "/file.bin")
+	if err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+
+	keptStat, err := os.Stat(kept)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cachedStat, err = os.Stat(loadDownloadIndex().file(url))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if os.SameFile(cachedStat, keptStat) {
+		t.Errorf("the second download was written into the file kept.bin links to")
+	}
+}
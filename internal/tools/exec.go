@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type ExecOptions struct {
+	// Resource location of the working directory. Empty runs in the current work dir.
+	Dir			string
+	// Variables added to the environment as 'NAME=value'.
+	Env			[]string
+	// Copies the output to the terminal as well as capturing it.
+	Stream		bool
+}
+
+type ExecResult struct {
+	Stdout		string
+	Stderr		string
+	Code		int
+}
+
+// ToolExec runs name with args and waits for it to exit. name is looked up in PATH unless it is a
+// resource location. A non-zero exit code is not an error, only failing to start or wait for the process is.
+func ToolExec(name string, args []string, opts ExecOptions) (ExecResult, error) {
+	if strings.HasPrefix(name, "/") || strings.HasPrefix(name, "./") || strings.HasPrefix(name, ":") {
+		path, err := fixPath(name)
+		if err != nil {
+			return ExecResult{Code: -1}, fmt.Errorf("failed to exec %s: %w", name, err)
+		}
+
+		name = path
+	}
+
+	cmd := exec.Command(name, args...)
+
+	if opts.Dir != "" {
+		dir, err := fixPath(opts.Dir)
+		if err != nil {
+			return ExecResult{Code: -1}, fmt.Errorf("failed to exec %s: %w", name, err)
+		}
+
+		cmd.Dir = dir
+	}
+
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Env...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if opts.Stream {
+		cmd.Stdout = io.MultiWriter(&stdout, os.Stdout)
+		cmd.Stderr = io.MultiWriter(&stderr, os.Stderr)
+	} else {
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+	}
+
+	err := cmd.Run()
+	result := ExecResult{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+		Code: 0,
+	}
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			result.Code = exitErr.ExitCode()
+			return result, nil
+		}
+
+		result.Code = -1
+		return result, fmt.Errorf("failed to exec %s: %w", name, err)
+	}
+
+	return result, nil
+}
+
+// SplitArgs splits a command line into arguments at whitespace. Single quotes keep their content as-is,
+// double quotes accept \" and \\, and a backslash outside quotes escapes the next character.
+func SplitArgs(line string) ([]string, error) {
+	var result []string
+	var current strings.Builder
+	inArg := false
+	quote := rune(0)
+	escaped := false
+
+	for _, ch := range line {
+		if escaped {
+			if quote == '"' && ch != '"' && ch != '\\' {
+				current.WriteRune('\\')
+			}
+
+			current.WriteRune(ch)
+			escaped = false
+			continue
+		}
+
+		switch {
+		case quote == '\'':
+			if ch == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(ch)
+			}
+		case quote == '"':
+			if ch == '"' {
+				quote = 0
+			} else if ch == '\\' {
+				escaped = true
+			} else {
+				current.WriteRune(ch)
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+			inArg = true
+		case ch == '\\':
+			escaped = true
+			inArg = true
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if inArg {
+				result = append(result, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(ch)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in '%s'", quote, line)
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash in '%s'", line)
+	}
+
+	if inArg {
+		result = append(result, current.String())
+	}
+
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// Digest is an expected checksum, written as '<algorithm>:<hex>'.
+type Digest struct {
+	Algorithm	string
+	Sum			string
+}
+
+func (d Digest) String() string {
+	return d.Algorithm + ":" + d.Sum
+}
+
+// ChecksumError is returned when downloaded content doesn't match the expected digest.
+type ChecksumError struct {
+	Url			string
+	Expected	Digest
+	Actual		Digest
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s. expected %s, got %s.", e.Url, e.Expected, e.Actual)
+}
+
+// ParseDigest reads 'sha256:<hex>' or 'sha512:<hex>'. A bare hex digest is accepted when its length
+// names the algorithm.
+func ParseDigest(digest string) (Digest, error) {
+	algorithm, sum, ok := strings.Cut(digest, ":")
+	if !ok {
+		sum = digest
+		switch len(sum) {
+		case sha256.Size * 2:
+			algorithm = "sha256"
+		case sha512.Size * 2:
+			algorithm = "sha512"
+		default:
+			return Digest{}, fmt.Errorf("failed to parse digest '%s'. expected '<algorithm>:<hex>'.", digest)
+		}
+	}
+
+	algorithm = strings.ToLower(algorithm)
+	h, err := newHash(algorithm)
+	if err != nil {
+		return Digest{}, fmt.Errorf("failed to parse digest '%s': %w", digest, err)
+	}
+
+	sum = strings.ToLower(sum)
+	if _, err := hex.DecodeString(sum); err != nil || len(sum) != h.Size() * 2 {
+		return Digest{}, fmt.Errorf("failed to parse digest '%s'. expected %d hex digits for %s.", digest, h.Size() * 2, algorithm)
+	}
+
+	return Digest{Algorithm: algorithm, Sum: sum}, nil
+}
+
+func newHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm '%s'. expected sha256 or sha512.", algorithm)
+	}
+}
+
+// ToolHash returns the hex digest of the file at src.
+func ToolHash(src, algorithm string) (string, error) {
+	path, err := fixPath(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", src, err)
+	}
+
+	sum, err := hashPath(path, strings.ToLower(algorithm))
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", src, err)
+	}
+
+	return sum, nil
+}
+
+func hashPath(path, algorithm string) (string, error) {
+	h, err := newHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	err = hashFile(h, path)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type HttpRequest struct {
+	Method		string
+	Url			string
+	Headers		map[string]string
+	// Sent as the request body when not empty.
+	Body		string
+}
+
+type HttpResponse struct {
+	Status		int
+	// Header names are lower case. Repeated headers are joined with ", ".
+	Headers		map[string]string
+	Body		string
+}
+
+// ToolHttp sends a single request and reads the whole response. Any status is a response, only
+// failing to reach the server or to read its answer is an error. The request uses the client and
+// timeout of the download config, and is never retried since it may not be safe to repeat.
+func ToolHttp(request HttpRequest) (HttpResponse, error) {
+	ctx := context.Background()
+	if downloadConfig.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, downloadConfig.Timeout)
+		defer cancel()
+	}
+
+	var body io.Reader
+	if request.Body != "" {
+		body = strings.NewReader(request.Body)
+	}
+
+	method := strings.ToUpper(request.Method)
+	req, err := http.NewRequestWithContext(ctx, method, request.Url, body)
+	if err != nil {
+		return HttpResponse{Status: -1}, fmt.Errorf("failed to send %s %s: %w", method, request.Url, err)
+	}
+
+	for name, value := range request.Headers {
+		req.Header.Set(name, value)
+	}
+	authorize(req)
+
+	resp, err := downloadConfig.Client.Do(req)
+	if err != nil {
+		return HttpResponse{Status: -1}, fmt.Errorf("failed to send %s %s: %w", method, request.Url, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return HttpResponse{Status: -1}, fmt.Errorf("failed to read response of %s %s: %w", method, request.Url, err)
+	}
+
+	headers := make(map[string]string, len(resp.Header))
+	for name, values := range resp.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ", ")
+	}
+
+	return HttpResponse{
+		Status: resp.StatusCode,
+		Headers: headers,
+		Body: string(data),
+	}, nil
+}
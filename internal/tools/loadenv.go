@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ToolLoadEnv sets the variables of a .env file. Variables that are already set keep their value.
+// Lines are 'NAME=value', optionally prefixed with 'export'. Blank lines and lines starting with '#'
+// are skipped. Values may be wrapped in single or double quotes. Returns how many variables were set.
+func ToolLoadEnv(src string) (int, error) {
+	path, err := fixPath(src)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load env %s: %w", src, err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load env %s: %w", src, err)
+	}
+	defer file.Close()
+
+	count := 0
+	lineNumber := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return count, fmt.Errorf("failed to load env %s. line %d is not NAME=value.", src, lineNumber)
+		}
+
+		if _, exists := os.LookupEnv(name); exists {
+			continue
+		}
+
+		err = os.Setenv(name, unquoteEnv(strings.TrimSpace(value)))
+		if err != nil {
+			return count, fmt.Errorf("failed to load env %s. failed to set '%s': %w", src, name, err)
+		}
+
+		count++
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return count, fmt.Errorf("failed to load env %s: %w", src, err)
+	}
+
+	return count, nil
+}
+
+// Double quoted values accept the escapes \n, \t, \" and \\. Single quoted values are taken as-is.
+// Unquoted values end at a ' #' comment.
+func unquoteEnv(value string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value) - 1] == '\'' {
+		return value[1:len(value) - 1]
+	}
+
+	if len(value) >= 2 && value[0] == '"' && value[len(value) - 1] == '"' {
+		replacer := strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+		return replacer.Replace(value[1:len(value) - 1])
+	}
+
+	if idx := strings.Index(value, " #"); idx != -1 {
+		value = strings.TrimSpace(value[:idx])
+	}
+
+	return value
+}
@@ -5,22 +5,22 @@ import (
 	"os"
 )
 
-func ToolLsd(dir string) (int64, error) {
+func ToolLsd(dir string) ([]string, error) {
 	path, err := fixPath(dir)
 	if err != nil {
-		return 0, fmt.Errorf("failed to list directory sub-dirs in %s: %w", dir, err)
+		return nil, fmt.Errorf("failed to list directory sub-dirs in %s: %w", dir, err)
 	}
 
 	list, err := os.ReadDir(path)
 	if err != nil {
-		return 0, fmt.Errorf("failed to list directory sub-dirs in %s: %w", dir, err)
+		return nil, fmt.Errorf("failed to list directory sub-dirs in %s: %w", dir, err)
 	}
 
-	var result int64
+	result := make([]string, 0, len(list))
 
 	for _, entry := range list {
 		if entry.Type().IsDir() {
-			result++
+			result = append(result, entry.Name())
 		}
 	}
 
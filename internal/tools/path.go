@@ -7,7 +7,8 @@ import (
 	"strings"
 )
 
-func locateGit() (string, error) {
+// LocateGit returns the closest directory holding a .git entry, starting at the working directory.
+func LocateGit() (string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
 		return "", fmt.Errorf("failed to get working directory: %w", err)
@@ -28,8 +29,10 @@ func locateGit() (string, error) {
 	}
 }
 
-func getTokenDir() (string, error) {
-	git, err := locateGit()
+// TokenDir returns the directory holding token files. A .wet directory next to .git is used when present,
+// otherwise ~/.wet, which is created on first use.
+func TokenDir() (string, error) {
+	git, err := LocateGit()
 	if err != nil {
 		return "", fmt.Errorf("failed to get token dir. failed to locate git: %w", err)
 	}
@@ -68,7 +71,7 @@ func getTokenDir() (string, error) {
 
 func fixPath(path string) (string, error) {
 	if strings.HasPrefix(path, "/") {
-		git, err := locateGit()
+		git, err := LocateGit()
 		if err != nil {
 			return "", fmt.Errorf("failed to fix path '%s'. failed to locate git: %w", path, err)
 		}
@@ -82,7 +85,7 @@ func fixPath(path string) (string, error) {
 
 		return cwd + path[1:], nil
 	} else if strings.HasPrefix(path, ":") {
-		wetDir, err := getTokenDir()
+		wetDir, err := TokenDir()
 		if err != nil {
 			return "", fmt.Errorf("failed to fix token '%s': %w", path, err)
 		}
@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	progressWidth = 30
+	progressInterval = 100 * time.Millisecond
+)
+
+// Draws a single-line progress bar while a download is written through it.
+type progress struct {
+	out			io.Writer
+	name		string
+	done		int64
+	// Size of the whole file, or -1 when the server didn't say.
+	total		int64
+	drawn		time.Time
+}
+
+func (p *progress) Write(data []byte) (int, error) {
+	p.done += int64(len(data))
+	if time.Since(p.drawn) >= progressInterval {
+		p.draw()
+	}
+
+	return len(data), nil
+}
+
+func (p *progress) draw() {
+	p.drawn = time.Now()
+
+	if p.total <= 0 {
+		fmt.Fprintf(p.out, "\r\033[K%s %s", p.name, formatBytes(p.done))
+		return
+	}
+
+	ratio := float64(p.done) / float64(p.total)
+	filled := min(int(ratio * progressWidth), progressWidth)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressWidth - filled)
+	fmt.Fprintf(p.out, "\r\033[K%s [%s] %3.0f%% %s / %s", p.name, bar, ratio * 100, formatBytes(p.done), formatBytes(p.total))
+}
+
+// Clears the bar, so whatever the script prints next starts on a clean line.
+func (p *progress) finish() {
+	fmt.Fprint(p.out, "\r\033[K")
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value := float64(size) / unit
+	suffix := "KiB"
+	for _, next := range []string{"MiB", "GiB", "TiB"} {
+		if value < unit {
+			break
+		}
+
+		value /= unit
+		suffix = next
+	}
+
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
@@ -2,6 +2,8 @@ package tools
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 )
 
@@ -9,6 +11,24 @@ func ToolToStringInt(value int) string {
 	return fmt.Sprintf("%d", value)
 }
 
+// Floats always render with a fraction, so 2.0 stays distinguishable from 2.
+func ToolToStringFloat(value float64) string {
+	result := strconv.FormatFloat(value, 'f', -1, 64)
+	if value == math.Trunc(value) && !math.IsInf(value, 0) {
+		result += ".0"
+	}
+
+	return result
+}
+
+func ToolToStringBool(value bool) string {
+	if value {
+		return "true"
+	}
+
+	return "false"
+}
+
 func ToolToStringString(value string) string {
 	return value
 }
@@ -8,22 +8,70 @@ const (
 	WetFlagVerboseCompile WetFlag = 0x4
 	WetFlagVerbose WetFlag = 0x7
 	WetFlagDev WetFlag = 0x8
-	WetFlagHelp WetFlag = 0x10
-	WetFlagVersion WetFlag = 0x20
-	WetFlagLicense WetFlag = 0x40
 )
 
 func (flag WetFlag) Is(other WetFlag) bool {
 	return flag & other == other
 }
 
+type WetCommand string
+const (
+	WetCommandRun WetCommand = "run"
+	WetCommandCheck WetCommand = "check"
+	WetCommandFmt WetCommand = "fmt"
+	WetCommandTokens WetCommand = "tokens"
+	WetCommandCache WetCommand = "cache"
+	WetCommandVersion WetCommand = "version"
+	WetCommandLicense WetCommand = "license"
+	WetCommandHelp WetCommand = "help"
+)
+
 type WetBin struct {
 	Path			string
 	Name			string
 }
 
+type WetFmtOptions struct {
+	Write			bool
+	Check			bool
+}
+
+type WetCacheOptions struct {
+	Clear			bool
+	Path			bool
+}
+
+// Download settings used when the options aren't given.
+const (
+	DefaultDownloadTimeout = 30
+	DefaultDownloadRetries = 3
+)
+
+type WetDownloadOptions struct {
+	// Seconds to wait for a response or for more data. 0 waits forever.
+	Timeout			int
+	// Attempts made after the first one fails.
+	Retries			int
+	NoProgress		bool
+	// Downloads again instead of asking the server whether cached downloads changed.
+	Refresh			bool
+}
+
 type WetArgs struct {
 	Bin				WetBin
+	Command			WetCommand
 	Flags			WetFlag
 	Path			*string
+	// Files given to commands taking more than one, such as fmt.
+	Files			[]string
+	// Everything after '--', forwarded to the script.
+	ScriptArgs		[]string
+	// Command to show help for. Empty for the overview.
+	Topic			string
+	Defines			map[string]string
+	IncludePaths	[]string
+	IncludeDepth	int
+	Fmt				WetFmtOptions
+	Cache			WetCacheOptions
+	Download		WetDownloadOptions
 }
@@ -0,0 +1,14 @@
+package types
+
+type ExitCode int
+const (
+	ExitCodeSuccess ExitCode = 0
+	ExitCodeScript ExitCode = 1
+	ExitCodeTool ExitCode = 2
+	ExitCodeExit ExitCode = 3
+	ExitCodeUsage ExitCode = 4
+)
+
+type ExitCoder interface {
+	ExitCode() ExitCode
+}
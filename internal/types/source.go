@@ -0,0 +1,99 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SourceLine struct {
+	File		string
+	Line		int
+	Text		string
+	// Set on lines of the embedded std, which no file on disk can claim.
+	Std			bool
+}
+
+type Position struct {
+	File		string
+	Line		int
+	Col			int
+	Text		string
+	Std			bool
+}
+
+func SplitSource(file, text string) []SourceLine {
+	lines := strings.Split(text, "\n")
+	result := make([]SourceLine, len(lines))
+
+	for idx, line := range lines {
+		result[idx] = SourceLine{
+			File: file,
+			Line: idx + 1,
+			Text: strings.TrimSuffix(line, "\r"),
+		}
+	}
+
+	return result
+}
+
+func JoinSource(lines []SourceLine) string {
+	parts := make([]string, len(lines))
+	for idx, line := range lines {
+		parts[idx] = line.Text
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+func (p Position) IsValid() bool {
+	return p.File != "" && p.Line > 0
+}
+
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "<unknown>"
+	}
+
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Col)
+}
+
+// Quote renders the source line of the position with a caret under the column.
+func (p Position) Quote() string {
+	if !p.IsValid() {
+		return ""
+	}
+
+	gutter := fmt.Sprintf("%d", p.Line)
+	text := strings.ReplaceAll(p.Text, "\t", " ")
+
+	col := p.Col - 1
+	if col < 0 {
+		col = 0
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, " %s | %s\n", gutter, text)
+	fmt.Fprintf(&sb, " %s | %s^", strings.Repeat(" ", len(gutter)), strings.Repeat(" ", col))
+
+	return sb.String()
+}
+
+type SourceError struct {
+	Pos			Position
+	Message		string
+}
+
+func NewSourceError(pos Position, format string, args...any) *SourceError {
+	return &SourceError{
+		Pos: pos,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (se *SourceError) Error() string {
+	if !se.Pos.IsValid() {
+		return se.Message
+	}
+
+	return fmt.Sprintf("%s: %s\n%s", se.Pos.String(), se.Message, se.Pos.Quote())
+}
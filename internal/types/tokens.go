@@ -19,6 +19,36 @@ const (
 type Token struct {
 	Value		string
 	Type		TokenType
+	Pos			Position
+	Expansion	*Expansion
+}
+
+// Expansion records the macro a token was expanded from and where that macro was used.
+// Parent is the expansion the call site itself came from, if any.
+type Expansion struct {
+	Macro		string
+	Params		[]string
+	Pos			Position
+	Parent		*Expansion
+}
+
+// Trace renders the expansion chain innermost first, e.g. "remove <- cleanup <- init.wet:4".
+func (e *Expansion) Trace() string {
+	if e == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	for it := e; it != nil; it = it.Parent {
+		sb.WriteString(it.Macro)
+		sb.WriteString(" <- ")
+
+		if it.Parent == nil {
+			fmt.Fprintf(&sb, "%s:%d", it.Pos.File, it.Pos.Line)
+		}
+	}
+
+	return sb.String()
 }
 
 func GetTokenTypeName(tokenType TokenType) string {
@@ -71,6 +101,14 @@ func (token *Token) Format() string {
 	}
 }
 
+func (t *Token) Errorf(format string, args...any) *SourceError {
+	if t == nil {
+		return NewSourceError(Position{}, format, args...)
+	}
+
+	return NewSourceError(t.Pos, format, args...)
+}
+
 func (t *Token) Equals(value string, ttype TokenType) bool {
 	if t == nil {
 		return false
@@ -120,6 +158,27 @@ func (t *Token) GetNumberValue() (int, bool) {
 	return 0, false
 }
 
+func (t *Token) GetFloatValue() (float64, bool) {
+	if t == nil {
+		return 0, false
+	}
+
+	if !t.Equals("", TokenTypeNumber) {
+		return 0, false
+	}
+
+	if t.Value == "" {
+		return 0, false
+	}
+
+	f, err := strconv.ParseFloat(t.Value, 64)
+	if err == nil {
+		return f, true
+	}
+
+	return 0, false
+}
+
 func (t *Token) GetStringValue() (string, bool) {
 	if t == nil {
 		return "", false
@@ -0,0 +1,569 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ktnuity/wet/internal/types"
+)
+
+// An option of a command. Exactly one setter is given, and it decides the type of the value.
+type option struct {
+	long		string
+	short		string
+	value		string
+	help		string
+	setBool		func(args *types.WetArgs, value bool)
+	setString	func(args *types.WetArgs, value string) error
+	setInt		func(args *types.WetArgs, value int) error
+}
+
+func (opt *option) takesValue() bool {
+	return opt.setBool == nil
+}
+
+// Renders the option as it appears in help, e.g. "-D, --define <name>[=<value>]".
+func (opt *option) usage() string {
+	var sb strings.Builder
+	if opt.short != "" {
+		fmt.Fprintf(&sb, "-%s, ", opt.short)
+	} else {
+		sb.WriteString("    ")
+	}
+
+	fmt.Fprintf(&sb, "--%s", opt.long)
+	if opt.value != "" {
+		fmt.Fprintf(&sb, " %s", opt.value)
+	}
+
+	return sb.String()
+}
+
+// What a command accepts besides options.
+type operands uint8
+const (
+	operandsNone operands = iota
+	operandsFile
+	operandsFiles
+	operandsTopic
+)
+
+type command struct {
+	name		types.WetCommand
+	summary		string
+	operands	operands
+	scriptArgs	bool
+	options		[]*option
+}
+
+func (cmd *command) usage(bin string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s", bin, cmd.name)
+
+	if len(cmd.options) > 0 {
+		sb.WriteString(" [options]")
+	}
+
+	switch cmd.operands {
+	case operandsFile:
+		sb.WriteString(" <file>")
+	case operandsFiles:
+		sb.WriteString(" <file>...")
+	case operandsTopic:
+		sb.WriteString(" [<command>]")
+	}
+
+	if cmd.scriptArgs {
+		sb.WriteString(" [--] [<args>...]")
+	}
+
+	return sb.String()
+}
+
+var helpOption = &option{
+	long: "help",
+	short: "h",
+	help: "show this help",
+}
+
+var verboseOptions = []*option{
+	{
+		long: "verbose",
+		short: "v",
+		help: "log tokenizing and every runtime step",
+		setBool: func(args *types.WetArgs, value bool) { setFlag(args, types.WetFlagVerbose, value) },
+	},
+	{
+		long: "verbose-runtime",
+		help: "log every runtime step",
+		setBool: func(args *types.WetArgs, value bool) { setFlag(args, types.WetFlagVerboseRuntime, value) },
+	},
+	{
+		long: "verbose-tokenize",
+		help: "log the tokens before running",
+		setBool: func(args *types.WetArgs, value bool) { setFlag(args, types.WetFlagVerboseTokenize, value) },
+	},
+	{
+		long: "dev",
+		help: "enable development mode",
+		setBool: func(args *types.WetArgs, value bool) { setFlag(args, types.WetFlagDev, value) },
+	},
+}
+
+var sourceOptions = []*option{
+	{
+		long: "define",
+		short: "D",
+		value: "<name>[=<value>]",
+		help: "define <name> for @if defined",
+		setString: func(args *types.WetArgs, value string) error {
+			name, define, _ := strings.Cut(value, "=")
+			if name == "" {
+				return fmt.Errorf("invalid define '%s'. expected <name>[=<value>].", value)
+			}
+
+			args.Defines[name] = define
+			return nil
+		},
+	},
+	{
+		long: "include",
+		short: "I",
+		value: "<path>",
+		help: "search <path> for @include files",
+		setString: func(args *types.WetArgs, value string) error {
+			args.IncludePaths = append(args.IncludePaths, value)
+			return nil
+		},
+	},
+	{
+		long: "include-depth",
+		value: "<depth>",
+		help: "limit how deep @include may nest",
+		setInt: func(args *types.WetArgs, value int) error {
+			if value < 1 {
+				return fmt.Errorf("include depth must be at least 1.")
+			}
+
+			args.IncludeDepth = value
+			return nil
+		},
+	},
+}
+
+var downloadOptions = []*option{
+	{
+		long: "download-timeout",
+		value: "<seconds>",
+		help: "give up on a download that sends nothing for <seconds>, 0 waits forever",
+		setInt: func(args *types.WetArgs, value int) error {
+			if value < 0 {
+				return fmt.Errorf("download timeout can't be negative.")
+			}
+
+			args.Download.Timeout = value
+			return nil
+		},
+	},
+	{
+		long: "download-retries",
+		value: "<count>",
+		help: "retry a failed download up to <count> times",
+		setInt: func(args *types.WetArgs, value int) error {
+			if value < 0 {
+				return fmt.Errorf("download retries can't be negative.")
+			}
+
+			args.Download.Retries = value
+			return nil
+		},
+	},
+	{
+		long: "refresh",
+		help: "download again without asking the server whether cached downloads changed",
+		setBool: func(args *types.WetArgs, value bool) { args.Download.Refresh = value },
+	},
+	{
+		long: "no-progress",
+		help: "don't draw download progress",
+		setBool: func(args *types.WetArgs, value bool) { args.Download.NoProgress = value },
+	},
+}
+
+// Commands in the order help lists them.
+var commands = []*command{
+	{
+		name: types.WetCommandRun,
+		summary: "run a script, the default when wet is given a file",
+		operands: operandsFile,
+		scriptArgs: true,
+		options: slices.Concat(verboseOptions, sourceOptions, downloadOptions),
+	},
+	{
+		name: types.WetCommandCheck,
+		summary: "type-check a script without running it",
+		operands: operandsFile,
+		options: slices.Concat(verboseOptions, sourceOptions),
+	},
+	{
+		name: types.WetCommandFmt,
+		summary: "print scripts re-indented, or rewrite them with --write",
+		operands: operandsFiles,
+		options: []*option{
+			{
+				long: "write",
+				short: "w",
+				help: "rewrite the files instead of printing them",
+				setBool: func(args *types.WetArgs, value bool) { args.Fmt.Write = value },
+			},
+			{
+				long: "check",
+				help: "list files that are not formatted, and fail if there are any",
+				setBool: func(args *types.WetArgs, value bool) { args.Fmt.Check = value },
+			},
+		},
+	},
+	{
+		name: types.WetCommandTokens,
+		summary: "print the tokens of a script",
+		operands: operandsFile,
+		options: sourceOptions,
+	},
+	{
+		name: types.WetCommandCache,
+		summary: "list the token files and cached downloads",
+		options: []*option{
+			{
+				long: "clear",
+				help: "remove every cached download, keeping token files",
+				setBool: func(args *types.WetArgs, value bool) { args.Cache.Clear = value },
+			},
+			{
+				long: "path",
+				help: "print the cache directory only",
+				setBool: func(args *types.WetArgs, value bool) { args.Cache.Path = value },
+			},
+		},
+	},
+	{
+		name: types.WetCommandVersion,
+		summary: "show the wet version",
+	},
+	{
+		name: types.WetCommandLicense,
+		summary: "show the wet license",
+	},
+	{
+		name: types.WetCommandHelp,
+		summary: "show help for wet or a command",
+		operands: operandsTopic,
+	},
+}
+
+// Flags kept from before commands existed. Each maps to the command it now runs.
+var legacyFlags = map[string]types.WetCommand{
+	"--help": types.WetCommandHelp,
+	"-h": types.WetCommandHelp,
+	"--version": types.WetCommandVersion,
+	"--license": types.WetCommandLicense,
+}
+
+func setFlag(args *types.WetArgs, flag types.WetFlag, value bool) {
+	if value {
+		args.Flags |= flag
+	} else {
+		args.Flags &^= flag
+	}
+}
+
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if string(cmd.name) == name {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+func commandNames() []string {
+	names := make([]string, len(commands))
+	for idx, cmd := range commands {
+		names[idx] = string(cmd.name)
+	}
+
+	return names
+}
+
+// Parses the command line. The first argument picks the command. Anything else runs the
+// given file, so 'wet init.wet' is 'wet run init.wet'.
+func GetCommandArguments() (*types.WetArgs, error) {
+	argv := os.Args
+
+	binPath := os.Args[0]
+	tmpPath := strings.ReplaceAll(binPath, "\\", "/")
+	binName := tmpPath[strings.LastIndex(tmpPath, "/")+1:]
+
+	args := types.WetArgs{
+		Bin: types.WetBin{ Path: binPath, Name: binName, },
+		Flags: 0,
+		Path: nil,
+		Defines: make(map[string]string),
+		Download: types.WetDownloadOptions{
+			Timeout: types.DefaultDownloadTimeout,
+			Retries: types.DefaultDownloadRetries,
+		},
+	}
+
+	rest := argv[1:]
+	var cmd *command
+
+	if len(rest) == 0 {
+		cmd = findCommand(string(types.WetCommandHelp))
+	} else if found := findCommand(rest[0]); found != nil {
+		cmd = found
+		rest = rest[1:]
+	} else if legacy, ok := legacyFlags[rest[0]]; ok {
+		cmd = findCommand(string(legacy))
+		rest = rest[1:]
+	} else {
+		cmd = findCommand(string(types.WetCommandRun))
+	}
+
+	args.Command = cmd.name
+
+	err := parseCommand(cmd, rest, &args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &args, nil
+}
+
+func parseCommand(cmd *command, argv []string, args *types.WetArgs) error {
+	operands := make([]string, 0, 1)
+
+	for argi := 0; argi < len(argv); argi++ {
+		arg := argv[argi]
+
+		if arg == "--" {
+			if !cmd.scriptArgs {
+				return usageError(cmd, args, "'%s %s' does not take script arguments.", args.Bin.Name, cmd.name)
+			}
+
+			args.ScriptArgs = argv[argi + 1:]
+			break
+		}
+
+		if arg == "-" || !strings.HasPrefix(arg, "-") {
+			operands = append(operands, arg)
+
+			// Like 'go run', everything after the script belongs to the script. A '--' right after it is optional.
+			if cmd.scriptArgs {
+				rest := argv[argi + 1:]
+				if len(rest) > 0 && rest[0] == "--" {
+					rest = rest[1:]
+				}
+
+				args.ScriptArgs = rest
+				break
+			}
+
+			continue
+		}
+
+		var opt *option
+		var name, value string
+		var hasValue bool
+
+		if long, ok := strings.CutPrefix(arg, "--"); ok {
+			var key string
+			key, value, hasValue = strings.Cut(long, "=")
+			name = "--" + key
+			opt = findOption(cmd, func(opt *option) bool { return opt.long == key })
+		} else {
+			name, value = arg[:2], arg[2:]
+			hasValue = value != ""
+			opt = findOption(cmd, func(opt *option) bool { return opt.short == arg[1:2] })
+		}
+
+		if opt == nil {
+			return unknownOption(cmd, args, name)
+		}
+
+		if opt == helpOption {
+			args.Command = types.WetCommandHelp
+			args.Topic = string(cmd.name)
+			return nil
+		}
+
+		if !opt.takesValue() {
+			enabled := true
+			if hasValue {
+				parsed, err := strconv.ParseBool(value)
+				if err != nil {
+					return usageError(cmd, args, "option '%s' expects true or false, but got '%s'.", name, value)
+				}
+
+				enabled = parsed
+			}
+
+			opt.setBool(args, enabled)
+			continue
+		}
+
+		if !hasValue {
+			argi++
+			if argi >= len(argv) {
+				return usageError(cmd, args, "option '%s' expects a value %s.", name, opt.value)
+			}
+
+			value = argv[argi]
+		}
+
+		var err error
+		if opt.setInt != nil {
+			number, convErr := strconv.Atoi(value)
+			if convErr != nil {
+				return usageError(cmd, args, "option '%s' expects a number, but got '%s'.", name, value)
+			}
+
+			err = opt.setInt(args, number)
+		} else {
+			err = opt.setString(args, value)
+		}
+
+		if err != nil {
+			return usageError(cmd, args, "option '%s' failed. %v", name, err)
+		}
+	}
+
+	return applyOperands(cmd, args, operands)
+}
+
+func applyOperands(cmd *command, args *types.WetArgs, operands []string) error {
+	switch cmd.operands {
+	case operandsFile:
+		if len(operands) == 0 {
+			return usageError(cmd, args, "missing <file>.")
+		}
+
+		args.Path = AsRef(operands[0])
+		operands = operands[1:]
+	case operandsFiles:
+		if len(operands) == 0 {
+			return usageError(cmd, args, "missing <file>.")
+		}
+
+		args.Files = operands
+		operands = nil
+	case operandsTopic:
+		if len(operands) > 0 {
+			if findCommand(operands[0]) == nil {
+				return unknownCommand(cmd, args, operands[0])
+			}
+
+			args.Topic = operands[0]
+			operands = operands[1:]
+		}
+	}
+
+	if len(operands) > 0 {
+		return usageError(cmd, args, "unexpected argument '%s'.", operands[0])
+	}
+
+	return nil
+}
+
+func findOption(cmd *command, match func(opt *option) bool) *option {
+	if match(helpOption) {
+		return helpOption
+	}
+
+	for _, opt := range cmd.options {
+		if match(opt) {
+			return opt
+		}
+	}
+
+	return nil
+}
+
+func unknownOption(cmd *command, args *types.WetArgs, name string) error {
+	candidates := []string{"--" + helpOption.long}
+	for _, opt := range cmd.options {
+		candidates = append(candidates, "--" + opt.long)
+	}
+
+	if suggestion, ok := Suggest(name, candidates); ok {
+		return usageError(cmd, args, "unknown option '%s' for '%s %s'. did you mean '%s'?", name, args.Bin.Name, cmd.name, suggestion)
+	}
+
+	return usageError(cmd, args, "unknown option '%s' for '%s %s'.", name, args.Bin.Name, cmd.name)
+}
+
+func unknownCommand(cmd *command, args *types.WetArgs, name string) error {
+	if suggestion, ok := Suggest(name, commandNames()); ok {
+		return usageError(cmd, args, "unknown command '%s'. did you mean '%s'?", name, suggestion)
+	}
+
+	return usageError(cmd, args, "unknown command '%s'.", name)
+}
+
+// Usage errors end with a pointer to the help of the command they came from.
+func usageError(cmd *command, args *types.WetArgs, format string, values...any) error {
+	message := fmt.Sprintf(format, values...)
+	message = strings.ToUpper(message[:1]) + message[1:]
+
+	return &ArgError{
+		Message: fmt.Sprintf("%s\nUsage: %s\nRun '%s help %s' for more information.", message, cmd.usage(args.Bin.Name), args.Bin.Name, cmd.name),
+	}
+}
+
+// CommandHelp renders the usage, summary and options of the named command.
+func CommandHelp(bin, name string) string {
+	cmd := findCommand(name)
+	if cmd == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Usage: %s\n\n", cmd.usage(bin))
+	fmt.Fprintf(&sb, "%s%s.\n", strings.ToUpper(cmd.summary[:1]), cmd.summary[1:])
+
+	options := append(slices.Clone(cmd.options), helpOption)
+	width := 0
+	for _, opt := range options {
+		width = max(width, len(opt.usage()))
+	}
+
+	sb.WriteString("\nOptions:\n")
+	for _, opt := range options {
+		fmt.Fprintf(&sb, "  %-*s  %s\n", width, opt.usage(), opt.help)
+	}
+
+	return sb.String()
+}
+
+// Overview renders the usage of wet and the summary of every command.
+func Overview(bin string) string {
+	width := 0
+	for _, cmd := range commands {
+		width = max(width, len(cmd.name))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Usage: %s <command> [options]\n", bin)
+	fmt.Fprintf(&sb, "       %s [options] <file> [<args>...]\n\n", bin)
+
+	sb.WriteString("Commands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&sb, "  %-*s  %s\n", width, cmd.name, cmd.summary)
+	}
+
+	fmt.Fprintf(&sb, "\nRun '%s help <command>' for the options of a command.\n", bin)
+	return sb.String()
+}
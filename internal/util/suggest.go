@@ -0,0 +1,51 @@
+package util
+
+// EditDistance counts the single character insertions, deletions and substitutions between a and b.
+// Swapping two neighbouring characters counts as a single edit, as it is a common typo.
+func EditDistance(a, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+
+	before := make([]int, len(rb) + 1)
+	prev := make([]int, len(rb) + 1)
+	curr := make([]int, len(rb) + 1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i - 1] == rb[j - 1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j] + 1, curr[j - 1] + 1, prev[j - 1] + cost)
+			if i > 1 && j > 1 && ra[i - 1] == rb[j - 2] && ra[i - 2] == rb[j - 1] {
+				curr[j] = min(curr[j], before[j - 2] + 1)
+			}
+		}
+
+		before, prev, curr = prev, curr, before
+	}
+
+	return prev[len(rb)]
+}
+
+// Suggest picks the candidate closest to word, if it is close enough to be a likely typo.
+func Suggest(word string, candidates []string) (string, bool) {
+	limit := max(1, len([]rune(word)) / 3)
+
+	best := ""
+	bestDistance := limit + 1
+	for _, candidate := range candidates {
+		distance := EditDistance(word, candidate)
+		if distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+
+	return best, best != ""
+}
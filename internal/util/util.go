@@ -1,6 +1,7 @@
 package util
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -14,6 +15,12 @@ func AsRef[T any](value T) *T {
 
 func ExitWithError(err error, message *string) {
 	if err != nil {
+		var se *types.SourceError
+		if errors.As(err, &se) {
+			fmt.Printf("%v\n", se)
+			os.Exit(1)
+		}
+
 		if message != nil {
 			fmt.Printf("%s\n", *message)
 		}
@@ -22,48 +29,20 @@ func ExitWithError(err error, message *string) {
 	}
 }
 
-func GetCommandArguments() (*types.WetArgs, error) {
-	argv := os.Args
-	argc := len(argv)
-
-	binPath := os.Args[0]
-	tmpPath := strings.ReplaceAll(binPath, "\\", "/")
-	binName := tmpPath[strings.LastIndex(tmpPath, "/")+1:]
-
-	args := types.WetArgs{
-		Bin: types.WetBin{ Path: binPath, Name: binName, },
-		Flags: 0,
-		Path: nil,
+// ExitWithStatus exits with the code matching the kind of failure. Reporting is left to the caller.
+func ExitWithStatus(err error) {
+	if err == nil {
+		return
 	}
 
-	for argi := 1; argi < argc; argi++ {
-		if strings.HasPrefix(argv[argi], "--") {
-			switch argv[argi] {
-			case "--verbose-runtime":
-				args.Flags |= types.WetFlagVerboseRuntime
-			case "--verbose-tokenize":
-				args.Flags |= types.WetFlagVerboseTokenize
-			case "--verbose":
-				args.Flags |= types.WetFlagVerbose
-			case "--dev":
-				args.Flags |= types.WetFlagDev
-			case "--help":
-				args.Flags |= types.WetFlagHelp
-			case "--version":
-				args.Flags |= types.WetFlagVersion
-			case "--license":
-				args.Flags |= types.WetFlagLicense
-			}
-		} else if args.Path == nil {
-			args.Path = AsRef(argv[argi])
-		} else {
-			return nil, &ArgError{
-				Message: fmt.Sprintf("More than one file provided.\nUsage: %s [options] <file>", args.Bin.Name),
-			}
-		}
+	code := types.ExitCodeScript
+
+	var coder types.ExitCoder
+	if errors.As(err, &coder) {
+		code = coder.ExitCode()
 	}
 
-	return &args, nil
+	os.Exit(int(code))
 }
 
 type ArgError struct {
@@ -74,3 +53,7 @@ func (ae *ArgError) Error() string {
 	first, _, _ := strings.Cut(ae.Message, "\n")
 	return strings.ToLower(first)
 }
+
+func (ae *ArgError) ExitCode() types.ExitCode {
+	return types.ExitCodeUsage
+}